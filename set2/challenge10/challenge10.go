@@ -72,7 +72,7 @@ func xor(xs, ys []byte) ([]byte, error) {
 // - xor last ciphertext with plaintext block
 // - pass that to the block cipher
 // - get a new ciphertext
-func encryptAESWithCBC(plaintext, key, iv []byte) ([]byte, error) {
+func EncryptAESWithCBC(plaintext, key, iv []byte) ([]byte, error) {
 	// QUESTION: Does CBC block size have to be the length of the key?
 	var ciphertext, lastCiphertextBlock []byte
 	for _, b := range challenge7.Blocks(plaintext, len(key)) {
@@ -108,7 +108,7 @@ func encryptAESWithCBC(plaintext, key, iv []byte) ([]byte, error) {
 // - decrypt with block cipher
 // - xor decrypted result with last ciphertext block (not plaintext!)
 // - get a plaintext block
-func decryptAESWithCBC(ciphertext, key, iv []byte) ([]byte, error) {
+func DecryptAESWithCBC(ciphertext, key, iv []byte) ([]byte, error) {
 	// QUESTION: Does CBC block size have to be the length of the key?
 	var plaintext, lastCiphertextBlock []byte
 	for _, b := range challenge7.Blocks(ciphertext, len(key)) {
@@ -149,7 +149,7 @@ func decryptFile(path string, key, iv []byte) (string, error) {
 		return "", err
 	}
 
-	plaintext, err := decryptAESWithCBC(bytes, key, iv)
+	plaintext, err := DecryptAESWithCBC(bytes, key, iv)
 	if err != nil {
 		return "", err
 	}
@@ -160,12 +160,12 @@ func decryptFile(path string, key, iv []byte) (string, error) {
 // then decrypt it with the same key using AES With ECB, we get the plaintext
 // back.
 func checkAESWithCBC(plaintext, key, iv []byte) (bool, error) {
-	ciphertext, err := encryptAESWithCBC(plaintext, key, iv)
+	ciphertext, err := EncryptAESWithCBC(plaintext, key, iv)
 	if err != nil {
 		return false, err
 	}
 
-	decrypted, err := decryptAESWithCBC(ciphertext, key, iv)
+	decrypted, err := DecryptAESWithCBC(ciphertext, key, iv)
 	if err != nil {
 		return false, err
 	}
@@ -6,7 +6,7 @@ import (
 )
 
 // https://www.rfc-editor.org/rfc/rfc2315#:~:text=Some%20content%2Dencryption%20algorithms%20assume
-func padPKCS7(block string, wantBlockSize int) (string, error) {
+func PadPKCS7(block string, wantBlockSize int) (string, error) {
 	padding := wantBlockSize - len(block)
 	if padding < 0 {
 		return "", fmt.Errorf("len(block) must be smaller than wantBlockSize")
@@ -25,7 +25,7 @@ func Run() {
 	blockSize := 20
 	want := "YELLOW SUBMARINE\x04\x04\x04\x04"
 
-	got, err := padPKCS7(input, blockSize)
+	got, err := PadPKCS7(input, blockSize)
 	switch {
 	case err != nil:
 		log.Fatal(err)
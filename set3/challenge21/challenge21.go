@@ -0,0 +1,282 @@
+package challenge21
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+)
+
+// Parameters for the 32-bit Mersenne Twister. Names follow the pseudocode at
+// https://en.wikipedia.org/wiki/Mersenne_Twister#Algorithmic_detail.
+const (
+	n         = 624
+	m         = 397
+	a         = 0x9908B0DF
+	f         = 1812433253
+	lowerMask = 0x7FFFFFFF // (1 << r) - 1, where r = 31
+	upperMask = 0x80000000 // The w - r most significant bits, where w = 32
+)
+
+// MT19937's tempering parameters, exported so that challenge 23 can undo the
+// tempering with the same values.
+const (
+	TemperU = 11
+	TemperD = 0xFFFFFFFF
+	TemperS = 7
+	TemperB = 0x9D2C5680
+	TemperT = 15
+	TemperC = 0xEFC60000
+	TemperL = 18
+)
+
+// Parameters for the 64-bit Mersenne Twister.
+const (
+	n64         = 312
+	m64         = 156
+	a64         = 0xB5026F5AA96619E9
+	u64         = 29
+	d64         = 0x5555555555555555
+	s64         = 17
+	b64         = 0x71D67FFFEDA60000
+	t64         = 37
+	c64         = 0xFFF7EEE000000000
+	l64         = 43
+	f64         = 6364136223846793005
+	lowerMask64 = 0x7FFFFFFF         // (1 << r) - 1, where r = 31
+	upperMask64 = 0xFFFFFFFF80000000 // The w - r most significant bits, where w = 64
+)
+
+// The seed used by the reference implementation when none is given.
+const DefaultSeed = 5489
+
+// Number of 32-bit words in MT19937's state.
+const StateSize = n
+
+// MT19937 is the 32-bit Mersenne Twister.
+type MT19937 struct {
+	state [n]uint32
+	// Index of the next state word to temper and return. When it reaches n,
+	// the whole state is twisted before generating more output.
+	index int
+}
+
+// Returns a generator seeded with the given seed.
+func NewMT19937(seed uint32) *MT19937 {
+	mt := &MT19937{}
+	mt.Seed(seed)
+	return mt
+}
+
+// Returns a generator that continues from the given state, as though all n
+// state words had just been tempered and returned. The next call to Uint32
+// twists the state first.
+func NewMT19937FromState(state [StateSize]uint32) *MT19937 {
+	return &MT19937{state: state, index: n}
+}
+
+// Resets the generator's state from the seed. Each state word is derived
+// from the one before it, starting with the seed itself.
+func (mt *MT19937) Seed(seed uint32) {
+	mt.state[0] = seed
+	for i := 1; i < n; i++ {
+		prev := mt.state[i-1]
+		mt.state[i] = f*(prev^(prev>>30)) + uint32(i)
+	}
+	mt.index = n
+}
+
+// Generates the next n state words from the current ones.
+func (mt *MT19937) twist() {
+	for i := 0; i < n; i++ {
+		x := (mt.state[i] & upperMask) | (mt.state[(i+1)%n] & lowerMask)
+		xA := x >> 1
+		if x%2 != 0 {
+			xA ^= a
+		}
+		mt.state[i] = mt.state[(i+m)%n] ^ xA
+	}
+	mt.index = 0
+}
+
+// Returns the next 32-bit output.
+func (mt *MT19937) Uint32() uint32 {
+	if mt.index >= n {
+		mt.twist()
+	}
+
+	// Tempering: the state words themselves are well-distributed but
+	// linearly related; tempering improves the distribution of the high bits.
+	y := mt.state[mt.index]
+	y ^= (y >> TemperU) & TemperD
+	y ^= (y << TemperS) & TemperB
+	y ^= (y << TemperT) & TemperC
+	y ^= y >> TemperL
+
+	mt.index++
+	return y
+}
+
+// MT19937_64 is the 64-bit Mersenne Twister.
+type MT19937_64 struct {
+	state [n64]uint64
+	index int
+}
+
+// Returns a generator seeded with the given seed.
+func NewMT19937_64(seed uint64) *MT19937_64 {
+	mt := &MT19937_64{}
+	mt.Seed(seed)
+	return mt
+}
+
+// Resets the generator's state from the seed.
+func (mt *MT19937_64) Seed(seed uint64) {
+	mt.state[0] = seed
+	for i := 1; i < n64; i++ {
+		prev := mt.state[i-1]
+		mt.state[i] = f64*(prev^(prev>>62)) + uint64(i)
+	}
+	mt.index = n64
+}
+
+// Generates the next n64 state words from the current ones.
+func (mt *MT19937_64) twist() {
+	for i := 0; i < n64; i++ {
+		x := (mt.state[i] & upperMask64) | (mt.state[(i+1)%n64] & lowerMask64)
+		xA := x >> 1
+		if x%2 != 0 {
+			xA ^= a64
+		}
+		mt.state[i] = mt.state[(i+m64)%n64] ^ xA
+	}
+	mt.index = 0
+}
+
+// Returns the next 64-bit output.
+func (mt *MT19937_64) Uint64() uint64 {
+	if mt.index >= n64 {
+		mt.twist()
+	}
+
+	y := mt.state[mt.index]
+	y ^= (y >> u64) & d64
+	y ^= (y << s64) & b64
+	y ^= (y << t64) & c64
+	y ^= y >> l64
+
+	mt.index++
+	return y
+}
+
+// Source adapts an MT19937 to the math/rand.Source64 interface, so that it
+// can back a *rand.Rand.
+type Source struct {
+	mt *MT19937
+}
+
+// Returns a rand.Source64 backed by a 32-bit Mersenne Twister.
+func NewSource(seed int64) *Source {
+	src := &Source{mt: &MT19937{}}
+	src.Seed(seed)
+	return src
+}
+
+// Seeds the generator with the low 32 bits of the seed, since that's all
+// MT19937 can take.
+func (src *Source) Seed(seed int64) {
+	src.mt.Seed(uint32(seed))
+}
+
+// Combines two 32-bit outputs into a 64-bit one, high word first.
+func (src *Source) Uint64() uint64 {
+	hi := uint64(src.mt.Uint32())
+	lo := uint64(src.mt.Uint32())
+	return hi<<32 | lo
+}
+
+// Returns a non-negative 63-bit integer, by dropping the lowest bit of
+// Uint64.
+func (src *Source) Int63() int64 {
+	return int64(src.Uint64() >> 1)
+}
+
+// Source64 adapts an MT19937_64 to the math/rand.Source64 interface.
+type Source64 struct {
+	mt *MT19937_64
+}
+
+// Returns a rand.Source64 backed by a 64-bit Mersenne Twister.
+func NewSource64(seed int64) *Source64 {
+	src := &Source64{mt: &MT19937_64{}}
+	src.Seed(seed)
+	return src
+}
+
+func (src *Source64) Seed(seed int64) {
+	src.mt.Seed(uint64(seed))
+}
+
+func (src *Source64) Uint64() uint64 {
+	return src.mt.Uint64()
+}
+
+func (src *Source64) Int63() int64 {
+	return int64(src.mt.Uint64() >> 1)
+}
+
+// Make sure the adapters satisfy the interface at compile time.
+var (
+	_ rand.Source64 = (*Source)(nil)
+	_ rand.Source64 = (*Source64)(nil)
+)
+
+// Checks the first outputs of a default-seeded MT19937 and MT19937_64
+// against the reference implementations, along with the 10000th output of
+// each, which the C++ standard requires of std::mt19937 and std::mt19937_64.
+func checkReferenceOutputs() (bool, error) {
+	want32 := []uint32{3499211612, 581869302, 3890346734, 3586334585, 545404204}
+	mt := NewMT19937(DefaultSeed)
+	for i, want := range want32 {
+		if got := mt.Uint32(); got != want {
+			return false, fmt.Errorf("MT19937 output %d: got %d, want %d", i, got, want)
+		}
+	}
+	for i := len(want32); i < 9999; i++ {
+		mt.Uint32()
+	}
+	if got, want := mt.Uint32(), uint32(4123659995); got != want {
+		return false, fmt.Errorf("MT19937 output 10000: got %d, want %d", got, want)
+	}
+
+	want64 := []uint64{14514284786278117030, 4620546740167642908, 13109570281517897720}
+	mt64 := NewMT19937_64(DefaultSeed)
+	for i, want := range want64 {
+		if got := mt64.Uint64(); got != want {
+			return false, fmt.Errorf("MT19937_64 output %d: got %d, want %d", i, got, want)
+		}
+	}
+	for i := len(want64); i < 9999; i++ {
+		mt64.Uint64()
+	}
+	if got, want := mt64.Uint64(), uint64(9981545732273789042); got != want {
+		return false, fmt.Errorf("MT19937_64 output 10000: got %d, want %d", got, want)
+	}
+
+	return true, nil
+}
+
+func Run() {
+	ok, err := checkReferenceOutputs()
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkReferenceOutputs passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkReferenceOutputs failed"))
+	}
+
+	// The adapter lets the Mersenne Twister drive the rest of math/rand.
+	r := rand.New(NewSource(DefaultSeed))
+	fmt.Printf("rand.Intn(100) x5: %d %d %d %d %d\n", r.Intn(100), r.Intn(100), r.Intn(100), r.Intn(100), r.Intn(100))
+}
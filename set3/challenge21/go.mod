@@ -0,0 +1,3 @@
+module cryptopals/set3/challenge21
+
+go 1.15
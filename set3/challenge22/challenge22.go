@@ -0,0 +1,82 @@
+package challenge22
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"cryptopals/set3/challenge21"
+)
+
+// Clock lets the oracle wait around without the caller having to actually
+// wait. The challenge has the oracle sleep for up to 1000 seconds, twice.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// Uses the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// A clock whose time only moves when someone sleeps on it.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+// Returns a duration between 40 and 1000 seconds, inclusive.
+func randomWait() time.Duration {
+	return time.Duration(40+rand.Intn(1000-40+1)) * time.Second
+}
+
+// Waits a random number of seconds, seeds an MT19937 with the current Unix
+// timestamp, waits a random number of seconds again, then returns the
+// generator's first output. Also returns the seed so the caller can check
+// the crack.
+func timestampSeededOutput(clock Clock) (uint32, uint32) {
+	clock.Sleep(randomWait())
+	seed := uint32(clock.Now().Unix())
+	mt := challenge21.NewMT19937(seed)
+	clock.Sleep(randomWait())
+	return mt.Uint32(), seed
+}
+
+// Recovers the seed of an MT19937 whose first output was the given output,
+// assuming it was seeded with a Unix timestamp no more than window before
+// now. Tries the most recent timestamps first. The second return value is
+// false if no timestamp in the window produces the output.
+func CrackSeed(output uint32, now time.Time, window time.Duration) (uint32, bool) {
+	end := now.Unix()
+	start := now.Add(-window).Unix()
+	for ts := end; ts >= start; ts-- {
+		seed := uint32(ts)
+		if challenge21.NewMT19937(seed).Uint32() == output {
+			return seed, true
+		}
+	}
+	return 0, false
+}
+
+func Run() {
+	// Simulate the waiting rather than actually doing it -- the attack only
+	// cares about what time it is when the output comes back.
+	clock := &fakeClock{now: realClock{}.Now()}
+	output, wantSeed := timestampSeededOutput(clock)
+
+	// The oracle can't have waited more than 2000 seconds in total.
+	gotSeed, ok := CrackSeed(output, clock.Now(), 2000*time.Second)
+	switch {
+	case !ok:
+		log.Fatal(fmt.Errorf("no seed in the window produces output %d", output))
+	case gotSeed == wantSeed:
+		fmt.Printf("got expected seed: %d\n", gotSeed)
+	default:
+		fmt.Printf("got unexpected seed: %d (want %d)\n", gotSeed, wantSeed)
+	}
+}
@@ -0,0 +1,81 @@
+package challenge23
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+
+	"cryptopals/set3/challenge21"
+)
+
+// Inverts y ^= (y >> shift) & mask.
+//
+// The top shift bits of y went through unchanged, and each later group of
+// shift bits was XOR'd with the (masked) group above it. So once we know a
+// group of bits, we can recover the group below -- doing it for the whole
+// word at once, 32/shift times, recovers every bit.
+func undoRightShiftXOR(y uint32, shift uint, mask uint32) uint32 {
+	result := y
+	for i := uint(0); i*shift < 32; i++ {
+		result = y ^ ((result >> shift) & mask)
+	}
+	return result
+}
+
+// Inverts y ^= (y << shift) & mask. Same idea as undoRightShiftXOR, working
+// up from the bottom bits instead.
+func undoLeftShiftXOR(y uint32, shift uint, mask uint32) uint32 {
+	result := y
+	for i := uint(0); i*shift < 32; i++ {
+		result = y ^ ((result << shift) & mask)
+	}
+	return result
+}
+
+// Recovers the MT19937 state word that tempered to y, by undoing the
+// tempering steps in reverse order.
+func Untemper(y uint32) uint32 {
+	y = undoRightShiftXOR(y, challenge21.TemperL, 0xFFFFFFFF)
+	y = undoLeftShiftXOR(y, challenge21.TemperT, challenge21.TemperC)
+	y = undoLeftShiftXOR(y, challenge21.TemperS, challenge21.TemperB)
+	y = undoRightShiftXOR(y, challenge21.TemperU, challenge21.TemperD)
+	return y
+}
+
+// Taps StateSize outputs from the generator and splices them into a new
+// generator which will produce the same outputs as the original from here
+// on.
+func Clone(mt *challenge21.MT19937) *challenge21.MT19937 {
+	var state [challenge21.StateSize]uint32
+	for i := range state {
+		state[i] = Untemper(mt.Uint32())
+	}
+	return challenge21.NewMT19937FromState(state)
+}
+
+// Checks that a clone of a randomly-seeded generator predicts the original's
+// next outputs.
+func checkClone(numOutputs int) (bool, error) {
+	original := challenge21.NewMT19937(rand.Uint32())
+	clone := Clone(original)
+
+	for i := 0; i < numOutputs; i++ {
+		want, got := original.Uint32(), clone.Uint32()
+		if got != want {
+			return false, fmt.Errorf("output %d after cloning: got %d, want %d", i, got, want)
+		}
+	}
+	return true, nil
+}
+
+func Run() {
+	ok, err := checkClone(10 * challenge21.StateSize)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkClone passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkClone failed"))
+	}
+}
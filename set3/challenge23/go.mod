@@ -0,0 +1,3 @@
+module cryptopals/set3/challenge23
+
+go 1.15
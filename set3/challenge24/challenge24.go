@@ -0,0 +1,137 @@
+package challenge24
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"cryptopals/set3/challenge21"
+)
+
+// A stream cipher whose keystream is the low 8 bits of each MT19937 output.
+type mtStream struct {
+	mt *challenge21.MT19937
+}
+
+// Returns the MT19937 stream cipher keyed with a 16-bit seed. It satisfies
+// cipher.Stream, so anything that attacks a stream cipher generically can
+// attack this one too.
+func NewMTStream(seed uint16) cipher.Stream {
+	return &mtStream{mt: challenge21.NewMT19937(uint32(seed))}
+}
+
+// XORs each byte of src with the next keystream byte and writes the result
+// to dst. Encryption and decryption are the same operation.
+func (s *mtStream) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("challenge24: output smaller than input")
+	}
+	for i, b := range src {
+		dst[i] = b ^ byte(s.mt.Uint32())
+	}
+}
+
+// Returns n bytes of MT19937 keystream for the given 32-bit seed.
+func keystream(seed uint32, n int) []byte {
+	s := &mtStream{mt: challenge21.NewMT19937(seed)}
+	ks := make([]byte, n)
+	s.XORKeyStream(ks, ks)
+	return ks
+}
+
+// Returns between 5 and 20 random bytes.
+func randomPrefix() []byte {
+	prefix := make([]byte, 5+rand.Intn(16))
+	rand.Read(prefix)
+	return prefix
+}
+
+// Encrypts a random prefix followed by the known plaintext under the given
+// stream.
+func encryptWithRandomPrefix(stream cipher.Stream, known []byte) []byte {
+	plaintext := append(randomPrefix(), known...)
+	ciphertext := make([]byte, len(plaintext))
+	stream.XORKeyStream(ciphertext, plaintext)
+	return ciphertext
+}
+
+// Recovers the 16-bit key of a stream cipher by trying every key until one
+// decrypts the end of the ciphertext to the known suffix. newStream builds
+// the cipher for a candidate key.
+func RecoverSeed(ciphertext, knownSuffix []byte, newStream func(seed uint16) cipher.Stream) (uint16, bool) {
+	if len(knownSuffix) > len(ciphertext) {
+		return 0, false
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	for seed := 0; seed <= 0xFFFF; seed++ {
+		newStream(uint16(seed)).XORKeyStream(plaintext, ciphertext)
+		if bytes.HasSuffix(plaintext, knownSuffix) {
+			return uint16(seed), true
+		}
+	}
+	return 0, false
+}
+
+// Generates a password reset token from an MT19937 seeded with the time.
+func passwordResetToken(now time.Time, length int) []byte {
+	return keystream(uint32(now.Unix()), length)
+}
+
+// Reports whether the token is the keystream of an MT19937 seeded with a
+// Unix timestamp no more than window before now.
+func IsTimeSeededToken(token []byte, now time.Time, window time.Duration) bool {
+	end := now.Unix()
+	start := now.Add(-window).Unix()
+	for ts := end; ts >= start; ts-- {
+		if bytes.Equal(keystream(uint32(ts), len(token)), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks that encrypting then decrypting with the same seed gives back the
+// plaintext.
+func checkMTStream(plaintext []byte, seed uint16) bool {
+	ciphertext := make([]byte, len(plaintext))
+	NewMTStream(seed).XORKeyStream(ciphertext, plaintext)
+
+	decrypted := make([]byte, len(ciphertext))
+	NewMTStream(seed).XORKeyStream(decrypted, ciphertext)
+
+	return string(decrypted) == string(plaintext)
+}
+
+func Run() {
+	if !checkMTStream([]byte("encrypt, decrypt, & check result"), 1234) {
+		log.Fatal(fmt.Errorf("checkMTStream failed"))
+	}
+	fmt.Println("checkMTStream passed")
+
+	// Recover the key from a ciphertext whose plaintext ends in 14 A's.
+	known := []byte("AAAAAAAAAAAAAA")
+	wantSeed := uint16(rand.Intn(0x10000))
+	ciphertext := encryptWithRandomPrefix(NewMTStream(wantSeed), known)
+
+	gotSeed, ok := RecoverSeed(ciphertext, known, NewMTStream)
+	switch {
+	case !ok:
+		log.Fatal(fmt.Errorf("no 16-bit seed decrypts to the known suffix"))
+	case gotSeed == wantSeed:
+		fmt.Printf("got expected seed: %d\n", gotSeed)
+	default:
+		fmt.Printf("got unexpected seed: %d (want %d)\n", gotSeed, wantSeed)
+	}
+
+	// A token generated a few minutes ago from the time should be detected;
+	// one from a random seed shouldn't.
+	now := time.Now()
+	timeSeeded := passwordResetToken(now.Add(-3*time.Minute), 16)
+	notTimeSeeded := keystream(rand.Uint32(), 16)
+	fmt.Printf("time-seeded token detected: %t\n", IsTimeSeededToken(timeSeeded, now, time.Hour))
+	fmt.Printf("random-seeded token detected: %t\n", IsTimeSeededToken(notTimeSeeded, now, time.Hour))
+}
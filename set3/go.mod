@@ -0,0 +1,18 @@
+module cryptopals/set3
+
+go 1.15
+
+replace cryptopals/set3/challenge21 => ./challenge21
+
+require (
+	cryptopals/set3/challenge21 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set3/challenge22 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set3/challenge23 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set3/challenge24 v0.0.0-00010101000000-000000000000 // indirect
+)
+
+replace cryptopals/set3/challenge22 => ./challenge22
+
+replace cryptopals/set3/challenge23 => ./challenge23
+
+replace cryptopals/set3/challenge24 => ./challenge24
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	"cryptopals/set3/challenge21"
+	"cryptopals/set3/challenge22"
+	"cryptopals/set3/challenge23"
+	"cryptopals/set3/challenge24"
+)
+
+func runChallenge(runFn func(), challengeNumber int) {
+	fmt.Printf("Challenge %d:\n", challengeNumber)
+	runFn()
+	fmt.Println()
+}
+
+func main() {
+	runChallenge(challenge21.Run, 21)
+	runChallenge(challenge22.Run, 22)
+	runChallenge(challenge23.Run, 23)
+	runChallenge(challenge24.Run, 24)
+}
@@ -0,0 +1,233 @@
+package challenge25
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"cryptopals/set1/challenge7"
+)
+
+// CTR mode as cryptopals describes it: the block cipher encrypts a counter
+// block made of a 64-bit little-endian nonce followed by a 64-bit
+// little-endian block count, and the result is XOR'd with the plaintext.
+// Because the keystream for any block only depends on its position, we can
+// generate the keystream for any part of the message without generating the
+// rest of it.
+// https://en.wikipedia.org/wiki/Block_cipher_mode_of_operation#Counter_(CTR)
+
+// Returns n bytes of keystream, starting offset bytes into the stream.
+func keystreamAt(block cipher.Block, nonce uint64, offset, n int) []byte {
+	blockSize := block.BlockSize()
+	firstBlock := offset / blockSize
+	lastBlock := (offset + n + blockSize - 1) / blockSize
+
+	counterBlock := make([]byte, blockSize)
+	binary.LittleEndian.PutUint64(counterBlock[0:8], nonce)
+
+	ks := make([]byte, 0, (lastBlock-firstBlock)*blockSize)
+	encrypted := make([]byte, blockSize)
+	for count := firstBlock; count < lastBlock; count++ {
+		binary.LittleEndian.PutUint64(counterBlock[8:16], uint64(count))
+		block.Encrypt(encrypted, counterBlock)
+		ks = append(ks, encrypted...)
+	}
+
+	// Drop the part of the first block before offset, and the part of the
+	// last block after offset+n.
+	start := offset - firstBlock*blockSize
+	return ks[start : start+n]
+}
+
+// A CTR-mode cipher.Stream which keeps track of how far into the keystream
+// it is.
+type ctrStream struct {
+	block  cipher.Block
+	nonce  uint64
+	offset int
+}
+
+// Returns an AES-CTR cipher.Stream for the key and nonce.
+func NewAESCTR(key []byte, nonce uint64) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ctrStream{block: block, nonce: nonce}, nil
+}
+
+func (s *ctrStream) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("challenge25: output smaller than input")
+	}
+	ks := keystreamAt(s.block, s.nonce, s.offset, len(src))
+	for i, b := range src {
+		dst[i] = b ^ ks[i]
+	}
+	s.offset += len(src)
+}
+
+// Encrypts the plaintext with AES in CTR mode.
+func EncryptAESWithCTR(plaintext, key []byte, nonce uint64) ([]byte, error) {
+	stream, err := NewAESCTR(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(plaintext))
+	stream.XORKeyStream(ciphertext, plaintext)
+	return ciphertext, nil
+}
+
+// Decrypts the ciphertext with AES in CTR mode. This is the same operation as
+// encrypting.
+func DecryptAESWithCTR(ciphertext, key []byte, nonce uint64) ([]byte, error) {
+	return EncryptAESWithCTR(ciphertext, key, nonce)
+}
+
+// Returns a copy of the ciphertext in which the plaintext starting at offset
+// has been replaced with newtext. Only the keystream under newtext is
+// generated, so the cost doesn't depend on the length of the ciphertext.
+// newtext may run past the end of the ciphertext, in which case the result is
+// longer, but it can't start past the end.
+func Edit(ciphertext, key []byte, nonce uint64, offset int, newtext []byte) ([]byte, error) {
+	if offset < 0 || offset > len(ciphertext) {
+		return nil, fmt.Errorf("offset %d is outside the ciphertext (length %d)", offset, len(ciphertext))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	edited := make([]byte, len(ciphertext))
+	copy(edited, ciphertext)
+	if end := offset + len(newtext); end > len(edited) {
+		edited = append(edited, make([]byte, end-len(edited))...)
+	}
+
+	ks := keystreamAt(block, nonce, offset, len(newtext))
+	for i, b := range newtext {
+		edited[offset+i] = b ^ ks[i]
+	}
+	return edited, nil
+}
+
+// Recovers the plaintext using only an edit function with a hidden key.
+// Editing in all zeroes replaces the ciphertext with the keystream itself, and
+// XORing the keystream with the original ciphertext gives the plaintext.
+func recoverPlaintext(ciphertext []byte, edit func(ciphertext []byte, offset int, newtext []byte) ([]byte, error)) ([]byte, error) {
+	ks, err := edit(ciphertext, 0, make([]byte, len(ciphertext)))
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	for i, c := range ciphertext {
+		plaintext[i] = c ^ ks[i]
+	}
+	return plaintext, nil
+}
+
+// Returns 16 random bytes.
+func randomKey() ([]byte, error) {
+	key := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Check that editing the ciphertext gives the same result as editing the
+// plaintext and encrypting all of it again.
+func checkEdit(plaintext, key []byte, nonce uint64, offset int, newtext []byte) (bool, error) {
+	ciphertext, err := EncryptAESWithCTR(plaintext, key, nonce)
+	if err != nil {
+		return false, err
+	}
+	edited, err := Edit(ciphertext, key, nonce, offset, newtext)
+	if err != nil {
+		return false, err
+	}
+
+	wantPlaintext := make([]byte, len(plaintext))
+	copy(wantPlaintext, plaintext)
+	if end := offset + len(newtext); end > len(wantPlaintext) {
+		wantPlaintext = append(wantPlaintext, make([]byte, end-len(wantPlaintext))...)
+	}
+	copy(wantPlaintext[offset:], newtext)
+	want, err := EncryptAESWithCTR(wantPlaintext, key, nonce)
+	if err != nil {
+		return false, err
+	}
+
+	return string(edited) == string(want), nil
+}
+
+func Run() {
+	key, err := randomKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var nonce uint64
+
+	// The file is challenge 7's, encrypted under ECB with "YELLOW SUBMARINE".
+	raw, err := ioutil.ReadFile("/home/swalters4925/cryptopals/set1/challenge7/data.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	bytes, err := base64.StdEncoding.DecodeString(string(raw))
+	if err != nil {
+		log.Fatal(err)
+	}
+	plaintext, err := challenge7.DecryptAESWithECB(bytes, []byte("YELLOW SUBMARINE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Edits inside one block, across block boundaries, and past the end.
+	edits := []struct {
+		offset  int
+		newtext string
+	}{
+		{offset: 3, newtext: "abc"},
+		{offset: 10, newtext: "spans two blocks"},
+		{offset: 30, newtext: "spans three blocks, which is more"},
+		{offset: len(plaintext) - 5, newtext: "runs past the end"},
+		{offset: len(plaintext), newtext: "appended"},
+	}
+	for _, e := range edits {
+		ok, err := checkEdit(plaintext, key, nonce, e.offset, []byte(e.newtext))
+		switch {
+		case err != nil:
+			log.Fatal(err)
+		case ok:
+			fmt.Printf("checkEdit passed for offset %d\n", e.offset)
+		case !ok:
+			log.Fatal(fmt.Errorf("checkEdit failed for offset %d", e.offset))
+		}
+	}
+
+	// The attacker only gets the ciphertext and an edit function that
+	// closes over the key.
+	ciphertext, err := EncryptAESWithCTR(plaintext, key, nonce)
+	if err != nil {
+		log.Fatal(err)
+	}
+	edit := func(ciphertext []byte, offset int, newtext []byte) ([]byte, error) {
+		return Edit(ciphertext, key, nonce, offset, newtext)
+	}
+
+	recovered, err := recoverPlaintext(ciphertext, edit)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case string(recovered) == string(plaintext):
+		fmt.Printf("got expected plaintext: %q...\n", recovered[:33])
+	default:
+		fmt.Printf("got unexpected plaintext: %q...\n", recovered[:33])
+	}
+}
@@ -0,0 +1,3 @@
+module cryptopals/set4/challenge25
+
+go 1.15
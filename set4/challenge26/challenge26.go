@@ -0,0 +1,155 @@
+package challenge26
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strings"
+
+	"cryptopals/set4/challenge25"
+)
+
+const (
+	commentPrefix = "comment1=cooking%20MCs;userdata="
+	commentSuffix = ";comment2=%20like%20a%20pound%20of%20bacon"
+)
+
+// Quotes out the characters that separate keys and values, so that user data
+// can't add its own key=value pairs.
+var userDataEscaper = strings.NewReplacer(";", "%3B", "=", "%3D")
+
+// Escapes the user data and wraps it in the comment strings. Challenge 27's
+// CBC service wraps user data the same way.
+func WrapUserData(userData string) string {
+	return commentPrefix + userDataEscaper.Replace(userData) + commentSuffix
+}
+
+// Splits a string like "k1=v1;k2=v2" into its key/value pairs. Pairs without
+// an "=" are skipped.
+func parseKeyValues(s string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		result[kv[0]] = kv[1]
+	}
+	return result
+}
+
+// Reports whether the decrypted string contains the pair "admin=true".
+func isAdmin(plaintext string) bool {
+	return parseKeyValues(plaintext)["admin"] == "true"
+}
+
+// The challenge 16 service, encrypting with CTR instead of CBC. The key and
+// nonce are fixed when the service is created, and hidden from the attacker.
+type ctrService struct {
+	key   []byte
+	nonce uint64
+}
+
+func newCTRService() (*ctrService, error) {
+	key := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &ctrService{key: key}, nil
+}
+
+// Wraps and encrypts the user data.
+func (s *ctrService) encrypt(userData string) ([]byte, error) {
+	return challenge25.EncryptAESWithCTR([]byte(WrapUserData(userData)), s.key, s.nonce)
+}
+
+// Decrypts the ciphertext and checks whether it makes the user an admin.
+func (s *ctrService) isAdmin(ciphertext []byte) (bool, error) {
+	plaintext, err := challenge25.DecryptAESWithCTR(ciphertext, s.key, s.nonce)
+	if err != nil {
+		return false, err
+	}
+	return isAdmin(string(plaintext)), nil
+}
+
+// Finds where the user data starts in the ciphertext. In CTR mode each
+// ciphertext byte only depends on the plaintext byte in the same position, so
+// two ciphertexts first differ where the user data does.
+func findUserDataOffset(encrypt func(userData string) ([]byte, error)) (int, error) {
+	a, err := encrypt("A")
+	if err != nil {
+		return 0, err
+	}
+	b, err := encrypt("B")
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("user data doesn't change the ciphertext")
+}
+
+// Makes a ciphertext that decrypts to contain ";admin=true;". We submit user
+// data with no special characters, so nothing gets escaped, then flip the
+// ciphertext bits under it. Flipping a bit in a CTR ciphertext flips the same
+// bit of the plaintext and nothing else -- unlike CBC, no block gets
+// scrambled, so we don't need a sacrificial block in front.
+func forgeAdmin(encrypt func(userData string) ([]byte, error)) ([]byte, error) {
+	offset, err := findUserDataOffset(encrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	target := ";admin=true;"
+	userData := strings.Repeat("A", len(target))
+	ciphertext, err := encrypt(userData)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range target {
+		ciphertext[offset+i] ^= userData[i] ^ target[i]
+	}
+	return ciphertext, nil
+}
+
+func Run() {
+	service, err := newCTRService()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Submitting ";admin=true;" directly shouldn't work.
+	ciphertext, err := service.encrypt(";admin=true;")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ok, err := service.isAdmin(ciphertext)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		log.Fatal(fmt.Errorf("escaping failed: user data made the user an admin"))
+	case !ok:
+		fmt.Println("escaping passed")
+	}
+
+	forged, err := forgeAdmin(service.encrypt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ok, err = service.isAdmin(forged)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("got expected result: forged ciphertext is admin")
+	default:
+		fmt.Println("got unexpected result: forged ciphertext isn't admin")
+	}
+}
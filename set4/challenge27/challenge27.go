@@ -0,0 +1,123 @@
+package challenge27
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+
+	"cryptopals/set2/challenge10"
+	"cryptopals/set2/challenge9"
+	"cryptopals/set4/challenge26"
+)
+
+// Returned by the receiver when the decrypted message isn't ASCII. It
+// carries the whole decrypted message, which is the mistake the attack
+// relies on.
+type highASCIIError struct {
+	plaintext []byte
+}
+
+func (e *highASCIIError) Error() string {
+	return fmt.Sprintf("message contains high-ASCII bytes: %q", e.plaintext)
+}
+
+// Wraps user data with challenge 26's WrapUserData and encrypts it with
+// challenge 10's CBC, using the key as the IV -- so the IV doesn't have to be
+// sent along with the message. It never parses the decrypted message, only
+// checks that it's ASCII.
+type keyAsIVService struct {
+	key []byte
+}
+
+func newKeyAsIVService() (*keyAsIVService, error) {
+	key := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &keyAsIVService{key: key}, nil
+}
+
+// Wraps, pads and encrypts the user data.
+func (s *keyAsIVService) encrypt(userData string) ([]byte, error) {
+	wrapped := challenge26.WrapUserData(userData)
+	padded, err := challenge9.PadPKCS7(wrapped, len(wrapped)+aes.BlockSize-len(wrapped)%aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return challenge10.EncryptAESWithCBC([]byte(padded), s.key, s.key)
+}
+
+// Decrypts the ciphertext and checks that every byte is ASCII. If one isn't,
+// the error includes the decrypted message.
+func (s *keyAsIVService) decrypt(ciphertext []byte) error {
+	plaintext, err := challenge10.DecryptAESWithCBC(ciphertext, s.key, s.key)
+	if err != nil {
+		return err
+	}
+	for _, b := range plaintext {
+		if b > 127 {
+			return &highASCIIError{plaintext: plaintext}
+		}
+	}
+	return nil
+}
+
+// Recovers the key from any ciphertext at least three blocks long.
+//
+// We send C_1, 0, C_1. The receiver decrypts:
+// - P'_1 = D(C_1) ^ IV = D(C_1) ^ key
+// - P'_2 = D(0) ^ C_1 (garbage, which makes the receiver complain)
+// - P'_3 = D(C_1) ^ 0 = D(C_1)
+// so P'_1 ^ P'_3 = key.
+func recoverKey(ciphertext []byte, decrypt func(ciphertext []byte) error) ([]byte, error) {
+	if len(ciphertext) < 3*aes.BlockSize {
+		return nil, fmt.Errorf("ciphertext must be at least three blocks long")
+	}
+	c1 := ciphertext[:aes.BlockSize]
+
+	var modified []byte
+	modified = append(modified, c1...)
+	modified = append(modified, make([]byte, aes.BlockSize)...)
+	modified = append(modified, c1...)
+
+	err := decrypt(modified)
+	var highASCII *highASCIIError
+	if !errors.As(err, &highASCII) {
+		return nil, fmt.Errorf("receiver didn't return the plaintext: %v", err)
+	}
+
+	p1 := highASCII.plaintext[:aes.BlockSize]
+	p3 := highASCII.plaintext[2*aes.BlockSize : 3*aes.BlockSize]
+	key := make([]byte, aes.BlockSize)
+	for i := range key {
+		key[i] = p1[i] ^ p3[i]
+	}
+	return key, nil
+}
+
+func Run() {
+	service, err := newKeyAsIVService()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ciphertext, err := service.encrypt("some user data")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := service.decrypt(ciphertext); err != nil {
+		log.Fatal(err)
+	}
+
+	key, err := recoverKey(ciphertext, service.decrypt)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case string(key) == string(service.key):
+		fmt.Printf("got expected key: %x\n", key)
+	default:
+		fmt.Printf("got unexpected key: %x (want %x)\n", key, service.key)
+	}
+}
@@ -0,0 +1,267 @@
+package challenge28
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log"
+	"math/bits"
+	"strings"
+)
+
+// SHA-1, written out so that its internal state can be read and replaced.
+// crypto/sha1 doesn't allow that, but length-extension attacks need it.
+// https://en.wikipedia.org/wiki/SHA-1#SHA-1_pseudocode
+
+const (
+	// Size of a SHA-1 digest in bytes.
+	Size = 20
+	// SHA-1 processes the message in blocks of this many bytes.
+	BlockSize = 64
+)
+
+// Initial register values.
+var initH = [5]uint32{0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476, 0xC3D2E1F0}
+
+// State is everything SHA-1 carries from one block to the next: the five
+// registers and the number of message bytes processed so far.
+type State struct {
+	H      [5]uint32
+	Length uint64
+}
+
+// SHA1 implements hash.Hash.
+type SHA1 struct {
+	h [5]uint32
+	// Bytes written but not yet processed, because they don't fill a block.
+	buf []byte
+	// Total number of bytes written.
+	length uint64
+}
+
+var _ hash.Hash = (*SHA1)(nil)
+
+// Returns a SHA-1 hash in its initial state.
+func New() *SHA1 {
+	d := &SHA1{}
+	d.Reset()
+	return d
+}
+
+// Returns a SHA-1 hash that picks up from the given state, as though
+// state.Length bytes had already been written. Since the registers only
+// change at block boundaries, state.Length must be a multiple of BlockSize.
+func NewFromState(state State) (*SHA1, error) {
+	if state.Length%BlockSize != 0 {
+		return nil, fmt.Errorf("state length %d is not a multiple of the block size", state.Length)
+	}
+	return &SHA1{h: state.H, length: state.Length}, nil
+}
+
+// Returns the registers and the number of bytes written. Bytes that don't
+// yet fill a block are counted in the length but haven't reached the
+// registers.
+func (d *SHA1) State() State {
+	return State{H: d.h, Length: d.length}
+}
+
+func (d *SHA1) Reset() {
+	d.h = initH
+	d.buf = nil
+	d.length = 0
+}
+
+func (d *SHA1) Size() int { return Size }
+
+func (d *SHA1) BlockSize() int { return BlockSize }
+
+// Processes as many whole blocks as there are, and keeps the rest for later.
+func (d *SHA1) Write(p []byte) (int, error) {
+	d.length += uint64(len(p))
+	d.buf = append(d.buf, p...)
+	for len(d.buf) >= BlockSize {
+		d.processBlock(d.buf[:BlockSize])
+		d.buf = d.buf[BlockSize:]
+	}
+	return len(p), nil
+}
+
+// Appends the digest to b. Doesn't change the hash's state, so more can be
+// written afterwards.
+func (d *SHA1) Sum(b []byte) []byte {
+	// Finish on a copy, so the caller can keep writing to d.
+	dCopy := *d
+	dCopy.buf = append([]byte(nil), d.buf...)
+	dCopy.Write(Padding(d.length))
+
+	digest := make([]byte, Size)
+	for i, h := range dCopy.h {
+		binary.BigEndian.PutUint32(digest[4*i:], h)
+	}
+	return append(b, digest...)
+}
+
+// Returns the padding SHA-1 appends to a message of the given length: a 1
+// bit, then 0 bits until the length is 8 bytes short of a block, then the
+// message length in bits as a 64-bit big-endian integer.
+func Padding(length uint64) []byte {
+	return MDPadding(length, BlockSize, binary.BigEndian)
+}
+
+// Returns the Merkle-Damgard padding for a message of the given length, for a
+// hash with the given block size and byte order: a 1 bit, then 0 bits until
+// the length is 8 bytes short of a block, then the message length in bits as
+// a 64-bit integer. SHA-1 and MD4 both pad this way, differing only in byte
+// order.
+func MDPadding(length uint64, blockSize int, order binary.ByteOrder) []byte {
+	padding := []byte{0x80}
+	for (length+uint64(len(padding)))%uint64(blockSize) != uint64(blockSize-8) {
+		padding = append(padding, 0)
+	}
+	bitLength := make([]byte, 8)
+	order.PutUint64(bitLength, length*8)
+	return append(padding, bitLength...)
+}
+
+// Mixes one 64-byte block into the registers.
+func (d *SHA1) processBlock(block []byte) {
+	// Break the block into sixteen 32-bit words, and extend them to eighty.
+	var w [80]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[4*i:])
+	}
+	for i := 16; i < 80; i++ {
+		w[i] = bits.RotateLeft32(w[i-3]^w[i-8]^w[i-14]^w[i-16], 1)
+	}
+
+	a, b, c, dd, e := d.h[0], d.h[1], d.h[2], d.h[3], d.h[4]
+	for i := 0; i < 80; i++ {
+		var f, k uint32
+		switch {
+		case i < 20:
+			f = (b & c) | (^b & dd)
+			k = 0x5A827999
+		case i < 40:
+			f = b ^ c ^ dd
+			k = 0x6ED9EBA1
+		case i < 60:
+			f = (b & c) | (b & dd) | (c & dd)
+			k = 0x8F1BBCDC
+		default:
+			f = b ^ c ^ dd
+			k = 0xCA62C1D6
+		}
+
+		temp := bits.RotateLeft32(a, 5) + f + e + k + w[i]
+		e = dd
+		dd = c
+		c = bits.RotateLeft32(b, 30)
+		b = a
+		a = temp
+	}
+
+	d.h[0] += a
+	d.h[1] += b
+	d.h[2] += c
+	d.h[3] += dd
+	d.h[4] += e
+}
+
+// Returns the SHA-1 digest of the data.
+func Sum(data []byte) []byte {
+	d := New()
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+// Authenticates the message with a secret-prefix MAC: SHA1(key || message).
+func MAC(key, message []byte) []byte {
+	d := New()
+	d.Write(key)
+	d.Write(message)
+	return d.Sum(nil)
+}
+
+// Reports whether mac is the secret-prefix MAC of the message under the key.
+func VerifyMAC(key, message, mac []byte) bool {
+	return subtle.ConstantTimeCompare(MAC(key, message), mac) == 1
+}
+
+// Checks the implementation against the standard test vectors, and against
+// crypto/sha1.
+func checkSHA1() (bool, error) {
+	vectors := []struct {
+		input string
+		want  string
+	}{
+		{"", "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		{"abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "84983e441c3bd26ebaae4aa1f95129e5e54670f1"},
+		{"The quick brown fox jumps over the lazy dog", "2fd4e1c67a2d28fced849ee1bb76e7391b93eb12"},
+		{strings.Repeat("a", 1000000), "34aa973cd4c4daa4f61eeb2bdbad27316534016f"},
+	}
+
+	for _, v := range vectors {
+		got := hex.EncodeToString(Sum([]byte(v.input)))
+		if got != v.want {
+			return false, fmt.Errorf("SHA-1 of %.20q...: got %s, want %s", v.input, got, v.want)
+		}
+		stdlib := sha1.Sum([]byte(v.input))
+		if got != hex.EncodeToString(stdlib[:]) {
+			return false, fmt.Errorf("SHA-1 of %.20q... doesn't match crypto/sha1", v.input)
+		}
+	}
+	return true, nil
+}
+
+// Checks that saving the state after some whole blocks and resuming from it
+// gives the same digest as hashing everything in one go.
+func checkResume(first, second []byte) (bool, error) {
+	d := New()
+	d.Write(first)
+	resumed, err := NewFromState(d.State())
+	if err != nil {
+		return false, err
+	}
+	resumed.Write(second)
+
+	return string(resumed.Sum(nil)) == string(Sum(append(first, second...))), nil
+}
+
+func Run() {
+	ok, err := checkSHA1()
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkSHA1 passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkSHA1 failed"))
+	}
+
+	ok, err = checkResume([]byte(strings.Repeat("x", 2*BlockSize)), []byte("and then some more"))
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkResume passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkResume failed"))
+	}
+
+	key := []byte("YELLOW SUBMARINE")
+	message := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	mac := MAC(key, message)
+	fmt.Printf("MAC: %x\n", mac)
+
+	// Changing the message without knowing the key shouldn't produce a valid
+	// MAC.
+	tampered := append([]byte(nil), message...)
+	tampered[len(tampered)-1] = 'X'
+	fmt.Printf("verifies original: %t\n", VerifyMAC(key, message, mac))
+	fmt.Printf("verifies tampered message: %t\n", VerifyMAC(key, tampered, mac))
+	fmt.Printf("verifies unkeyed hash: %t\n", VerifyMAC(key, message, Sum(message)))
+}
@@ -0,0 +1,142 @@
+package challenge29
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"log"
+	"math/big"
+	"strings"
+
+	"cryptopals/set4/challenge28"
+)
+
+// Length extension works on any Merkle-Damgard hash: the digest is just the
+// registers after the last block, so we can load it back into the hash and
+// keep going. The new hash behaves as though it had already processed
+// key || message || glue padding, where the glue padding is what the hash
+// appended to key || message to finish it off.
+// https://en.wikipedia.org/wiki/Length_extension_attack
+
+// MDHash describes a Merkle-Damgard hash closely enough to extend its
+// digests. Both SHA-1 and MD4 use 64-byte blocks and a 64-bit length.
+type MDHash struct {
+	// How the message length is encoded at the end of the padding.
+	ByteOrder binary.ByteOrder
+	// Returns a hash which continues from the digest, as though length
+	// bytes had already been written.
+	Resume func(digest []byte, length uint64) (hash.Hash, error)
+}
+
+const blockSize = 64
+
+// SHA1 extends digests produced by challenge28's SHA-1.
+var SHA1 = MDHash{
+	ByteOrder: binary.BigEndian,
+	Resume: func(digest []byte, length uint64) (hash.Hash, error) {
+		if len(digest) != challenge28.Size {
+			return nil, fmt.Errorf("SHA-1 digest must be %d bytes, got %d", challenge28.Size, len(digest))
+		}
+		var h [5]uint32
+		for i := range h {
+			h[i] = binary.BigEndian.Uint32(digest[4*i:])
+		}
+		return challenge28.NewFromState(challenge28.State{H: h, Length: length})
+	},
+}
+
+// Returns the padding a Merkle-Damgard hash with 64-byte blocks appends to a
+// message of the given length, with the length in the given byte order.
+func GluePadding(length uint64, order binary.ByteOrder) []byte {
+	return challenge28.MDPadding(length, blockSize, order)
+}
+
+// A forged message and the MAC that goes with it, assuming the key was
+// KeyLength bytes long.
+type Forgery struct {
+	KeyLength int
+	Message   []byte
+	MAC       []byte
+}
+
+// Forges a MAC for message || glue padding || extension, given the MAC of
+// message and a guess at the length of the key.
+func Extend(h MDHash, message, mac, extension []byte, keyLength int) (Forgery, error) {
+	originalLength := uint64(keyLength + len(message))
+	glue := GluePadding(originalLength, h.ByteOrder)
+
+	resumed, err := h.Resume(mac, originalLength+uint64(len(glue)))
+	if err != nil {
+		return Forgery{}, err
+	}
+	resumed.Write(extension)
+
+	var forgedMessage []byte
+	forgedMessage = append(forgedMessage, message...)
+	forgedMessage = append(forgedMessage, glue...)
+	forgedMessage = append(forgedMessage, extension...)
+	return Forgery{
+		KeyLength: keyLength,
+		Message:   forgedMessage,
+		MAC:       resumed.Sum(nil),
+	}, nil
+}
+
+// Tries each key length from minKeyLength to maxKeyLength, and returns the
+// first forgery that verify accepts.
+func Forge(h MDHash, message, mac, extension []byte, minKeyLength, maxKeyLength int, verify func(message, mac []byte) bool) (Forgery, error) {
+	for keyLength := minKeyLength; keyLength <= maxKeyLength; keyLength++ {
+		forgery, err := Extend(h, message, mac, extension, keyLength)
+		if err != nil {
+			return Forgery{}, err
+		}
+		if verify(forgery.Message, forgery.MAC) {
+			return forgery, nil
+		}
+	}
+	return Forgery{}, fmt.Errorf("no key length from %d to %d produced a valid MAC", minKeyLength, maxKeyLength)
+}
+
+// Returns a random key between 1 and 32 bytes long.
+func randomKey() ([]byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(32))
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, n.Int64()+1)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func Run() {
+	// The glue padding should be exactly what SHA-1 pads with.
+	for _, length := range []uint64{0, 55, 56, 64, 77} {
+		if string(GluePadding(length, binary.BigEndian)) != string(challenge28.Padding(length)) {
+			log.Fatal(fmt.Errorf("glue padding for length %d doesn't match SHA-1's padding", length))
+		}
+	}
+	fmt.Println("checkGluePadding passed")
+
+	key, err := randomKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	message := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	mac := challenge28.MAC(key, message)
+
+	verify := func(message, mac []byte) bool {
+		return challenge28.VerifyMAC(key, message, mac)
+	}
+	forgery, err := Forge(SHA1, message, mac, []byte(";admin=true"), 0, 64, verify)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case verify(forgery.Message, forgery.MAC) && strings.HasSuffix(string(forgery.Message), ";admin=true"):
+		fmt.Printf("got expected result: forged MAC %x verifies (key length %d)\n", forgery.MAC, forgery.KeyLength)
+	default:
+		fmt.Printf("got unexpected result: %+v\n", forgery)
+	}
+}
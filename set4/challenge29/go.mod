@@ -0,0 +1,3 @@
+module cryptopals/set4/challenge29
+
+go 1.15
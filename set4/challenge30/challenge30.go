@@ -0,0 +1,242 @@
+package challenge30
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log"
+	"math/bits"
+	"strings"
+
+	"cryptopals/set4/challenge28"
+	"cryptopals/set4/challenge29"
+)
+
+// MD4, with the same state API as challenge28's SHA-1.
+// https://www.rfc-editor.org/rfc/rfc1320
+
+const (
+	// Size of an MD4 digest in bytes.
+	Size = 16
+	// MD4 processes the message in blocks of this many bytes.
+	BlockSize = 64
+)
+
+// Initial register values.
+var initH = [4]uint32{0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476}
+
+// State is everything MD4 carries from one block to the next: the four
+// registers and the number of message bytes processed so far.
+type State struct {
+	H      [4]uint32
+	Length uint64
+}
+
+// MD4 implements hash.Hash.
+type MD4 struct {
+	h [4]uint32
+	// Bytes written but not yet processed, because they don't fill a block.
+	buf []byte
+	// Total number of bytes written.
+	length uint64
+}
+
+var _ hash.Hash = (*MD4)(nil)
+
+// Returns an MD4 hash in its initial state.
+func New() *MD4 {
+	d := &MD4{}
+	d.Reset()
+	return d
+}
+
+// Returns an MD4 hash that picks up from the given state, as though
+// state.Length bytes had already been written. state.Length must be a
+// multiple of BlockSize.
+func NewFromState(state State) (*MD4, error) {
+	if state.Length%BlockSize != 0 {
+		return nil, fmt.Errorf("state length %d is not a multiple of the block size", state.Length)
+	}
+	return &MD4{h: state.H, length: state.Length}, nil
+}
+
+// Returns the registers and the number of bytes written.
+func (d *MD4) State() State {
+	return State{H: d.h, Length: d.length}
+}
+
+func (d *MD4) Reset() {
+	d.h = initH
+	d.buf = nil
+	d.length = 0
+}
+
+func (d *MD4) Size() int { return Size }
+
+func (d *MD4) BlockSize() int { return BlockSize }
+
+// Processes as many whole blocks as there are, and keeps the rest for later.
+func (d *MD4) Write(p []byte) (int, error) {
+	d.length += uint64(len(p))
+	d.buf = append(d.buf, p...)
+	for len(d.buf) >= BlockSize {
+		d.processBlock(d.buf[:BlockSize])
+		d.buf = d.buf[BlockSize:]
+	}
+	return len(p), nil
+}
+
+// Appends the digest to b without changing the hash's state.
+func (d *MD4) Sum(b []byte) []byte {
+	dCopy := *d
+	dCopy.buf = append([]byte(nil), d.buf...)
+	dCopy.Write(Padding(d.length))
+
+	digest := make([]byte, Size)
+	for i, h := range dCopy.h {
+		binary.LittleEndian.PutUint32(digest[4*i:], h)
+	}
+	return append(b, digest...)
+}
+
+// Returns the padding MD4 appends to a message of the given length. It's the
+// same as SHA-1's, except that the length is little-endian.
+func Padding(length uint64) []byte {
+	return challenge28.MDPadding(length, BlockSize, binary.LittleEndian)
+}
+
+// The three rounds' auxiliary functions.
+func f(x, y, z uint32) uint32 { return (x & y) | (^x & z) }
+func g(x, y, z uint32) uint32 { return (x & y) | (x & z) | (y & z) }
+func h(x, y, z uint32) uint32 { return x ^ y ^ z }
+
+// For each round: the function, the constant added, the order the block's
+// words are used in, and the rotation for each of the four steps that
+// repeat through the round.
+var rounds = []struct {
+	fn     func(x, y, z uint32) uint32
+	k      uint32
+	order  [16]int
+	shifts [4]int
+}{
+	{f, 0, [16]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, [4]int{3, 7, 11, 19}},
+	{g, 0x5A827999, [16]int{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15}, [4]int{3, 5, 9, 13}},
+	{h, 0x6ED9EBA1, [16]int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}, [4]int{3, 9, 11, 15}},
+}
+
+// Mixes one 64-byte block into the registers.
+func (d *MD4) processBlock(block []byte) {
+	var x [16]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(block[4*i:])
+	}
+
+	// The RFC updates a, d, c, b, a, d, ... in turn, each time mixing in the
+	// other three in order. So step i updates register (-i mod 4).
+	v := d.h
+	for _, round := range rounds {
+		for i, k := range round.order {
+			r := (4 - i%4) % 4
+			sum := v[r] + round.fn(v[(r+1)%4], v[(r+2)%4], v[(r+3)%4]) + x[k] + round.k
+			v[r] = bits.RotateLeft32(sum, round.shifts[i%4])
+		}
+	}
+
+	for i := range d.h {
+		d.h[i] += v[i]
+	}
+}
+
+// Returns the MD4 digest of the data.
+func Sum(data []byte) []byte {
+	d := New()
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+// Authenticates the message with a secret-prefix MAC: MD4(key || message).
+func MAC(key, message []byte) []byte {
+	d := New()
+	d.Write(key)
+	d.Write(message)
+	return d.Sum(nil)
+}
+
+// Reports whether mac is the secret-prefix MAC of the message under the key.
+func VerifyMAC(key, message, mac []byte) bool {
+	return subtle.ConstantTimeCompare(MAC(key, message), mac) == 1
+}
+
+// Extends digests produced by MD4, for challenge29's forger.
+var MDHash = challenge29.MDHash{
+	ByteOrder: binary.LittleEndian,
+	Resume: func(digest []byte, length uint64) (hash.Hash, error) {
+		if len(digest) != Size {
+			return nil, fmt.Errorf("MD4 digest must be %d bytes, got %d", Size, len(digest))
+		}
+		var h [4]uint32
+		for i := range h {
+			h[i] = binary.LittleEndian.Uint32(digest[4*i:])
+		}
+		return NewFromState(State{H: h, Length: length})
+	},
+}
+
+// Checks the implementation against the test suite in RFC 1320.
+func checkMD4() (bool, error) {
+	vectors := []struct {
+		input string
+		want  string
+	}{
+		{"", "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{"a", "bde52cb31de33e46245e05fbdbd6fb24"},
+		{"abc", "a448017aaf21d8525fc10ae87aa6729d"},
+		{"message digest", "d9130a8164549fe818874806e1c7014b"},
+		{"abcdefghijklmnopqrstuvwxyz", "d79e1c308aa5bbcdeea8ed63df412da9"},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", "043f8582f241db351ce627e153e7f0e4"},
+		{strings.Repeat("1234567890", 8), "e33b4ddc9c38f2199c3e7b164fcc0536"},
+	}
+
+	for _, v := range vectors {
+		got := hex.EncodeToString(Sum([]byte(v.input)))
+		if got != v.want {
+			return false, fmt.Errorf("MD4 of %q: got %s, want %s", v.input, got, v.want)
+		}
+	}
+	return true, nil
+}
+
+func Run() {
+	ok, err := checkMD4()
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkMD4 passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkMD4 failed"))
+	}
+
+	key := make([]byte, 19)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	message := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	mac := MAC(key, message)
+
+	verify := func(message, mac []byte) bool {
+		return VerifyMAC(key, message, mac)
+	}
+	forgery, err := challenge29.Forge(MDHash, message, mac, []byte(";admin=true"), 0, 64, verify)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case verify(forgery.Message, forgery.MAC) && strings.HasSuffix(string(forgery.Message), ";admin=true"):
+		fmt.Printf("got expected result: forged MAC %x verifies (key length %d)\n", forgery.MAC, forgery.KeyLength)
+	default:
+		fmt.Printf("got unexpected result: %+v\n", forgery)
+	}
+}
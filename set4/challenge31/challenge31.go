@@ -0,0 +1,265 @@
+package challenge31
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"time"
+
+	"cryptopals/set4/challenge28"
+)
+
+// Returns the HMAC-SHA1 of the message, using challenge28's SHA-1.
+func HMACSHA1(key, message []byte) []byte {
+	mac := hmac.New(func() hash.Hash { return challenge28.New() }, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// Compares the slices a byte at a time, sleeping for delay after each byte
+// that matches and returning as soon as one doesn't. How long it takes
+// reveals how many leading bytes match.
+func insecureCompare(a, b []byte, delay time.Duration) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+		time.Sleep(delay)
+	}
+	return true
+}
+
+// Handles requests like /test?file=foo&signature=<hex HMAC-SHA1 of foo>,
+// answering 200 if the signature is valid and 500 if it isn't.
+type server struct {
+	key   []byte
+	delay time.Duration
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := r.URL.Query().Get("file")
+	signature, err := hex.DecodeString(r.URL.Query().Get("signature"))
+	if err != nil {
+		http.Error(w, "signature must be hex", http.StatusBadRequest)
+		return
+	}
+
+	if !insecureCompare(HMACSHA1(s.key, []byte(file)), signature, s.delay) {
+		http.Error(w, "invalid signature", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// Starts a local server which checks file signatures with the key, and
+// sleeps for delay per matching byte while it does. The caller should Close
+// it when done.
+func NewServer(key []byte, delay time.Duration) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/test", &server{key: key, delay: delay})
+	return httptest.NewServer(mux)
+}
+
+// Sends the file and signature to the server, and returns how long the server
+// took to answer and whether it accepted the signature.
+func timeRequest(client *http.Client, baseURL, file string, signature []byte) (time.Duration, bool, error) {
+	query := url.Values{}
+	query.Set("file", file)
+	query.Set("signature", hex.EncodeToString(signature))
+
+	start := time.Now()
+	resp, err := client.Get(baseURL + "/test?" + query.Encode())
+	if err != nil {
+		return 0, false, err
+	}
+	// Read the whole body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	return elapsed, resp.StatusCode == http.StatusOK, nil
+}
+
+// Times the request rounds times and returns the median, which isn't thrown
+// off by the occasional slow request the way the mean is.
+func medianTime(client *http.Client, baseURL, file string, signature []byte, rounds int) (time.Duration, bool, error) {
+	times := make([]time.Duration, 0, rounds)
+	for i := 0; i < rounds; i++ {
+		elapsed, ok, err := timeRequest(client, baseURL, file, signature)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			return elapsed, true, nil
+		}
+		times = append(times, elapsed)
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+	return times[len(times)/2], false, nil
+}
+
+type timedGuess struct {
+	b      byte
+	median time.Duration
+}
+
+// How many of the slowest guesses to time again, more carefully, before
+// picking one.
+const finalists = 5
+
+// What timing all 256 guesses for one position of the signature told us.
+type position struct {
+	// The slowest guess, which is most likely the right byte.
+	best timedGuess
+	// The median time over all guesses. Nearly all of them are wrong, so
+	// this is how long the server takes to reject the bytes before this
+	// position.
+	baseline time.Duration
+	// Whether the server accepted the signature outright.
+	accepted bool
+}
+
+// Times every byte at position i of the signature, given the bytes before
+// it. The right byte makes the server compare one more byte before bailing
+// out, so it should be the slowest.
+func timePosition(client *http.Client, baseURL, file string, signature []byte, i, rounds int) (position, error) {
+	guesses := make([]timedGuess, 0, 256)
+	for b := 0; b < 256; b++ {
+		signature[i] = byte(b)
+		median, ok, err := medianTime(client, baseURL, file, signature, rounds)
+		if err != nil {
+			return position{}, err
+		}
+		if ok {
+			return position{best: timedGuess{b: byte(b), median: median}, accepted: true}, nil
+		}
+		guesses = append(guesses, timedGuess{b: byte(b), median: median})
+	}
+
+	sort.Slice(guesses, func(i, j int) bool {
+		return guesses[i].median > guesses[j].median
+	})
+	result := position{baseline: guesses[len(guesses)/2].median}
+
+	// A first pass over all 256 bytes narrows it down; time the slowest few
+	// again with more rounds, to make sure noise didn't pick the winner.
+	for j, g := range guesses[:finalists] {
+		signature[i] = g.b
+		median, ok, err := medianTime(client, baseURL, file, signature, 4*rounds)
+		if err != nil {
+			return position{}, err
+		}
+		if ok {
+			return position{best: timedGuess{b: g.b, median: median}, accepted: true}, nil
+		}
+		if j == 0 || median > result.best.median {
+			result.best = timedGuess{b: g.b, median: median}
+		}
+	}
+	return result, nil
+}
+
+// Recovers a valid HMAC-SHA1 for the file a byte at a time, from how long the
+// server takes to reject wrong ones. Each guess is timed rounds times; raise
+// it when the per-byte delay is small compared to the network noise. progress
+// is called with the signature so far after each byte, if it's not nil.
+//
+// Noise can still make us pick a wrong byte now and then. When that happens,
+// the server stops comparing at that byte, so rejections at the next position
+// take no longer than they did at the wrong one. If that's what we see, we
+// go back and redo the previous byte.
+func RecoverMAC(baseURL, file string, rounds int, progress func(signature []byte, i int)) ([]byte, error) {
+	client := &http.Client{}
+	signature := make([]byte, challenge28.Size)
+	positions := make([]position, len(signature))
+
+	// Give up rather than go back and forth forever.
+	retriesLeft := 2 * len(signature)
+
+	for i := 0; i < len(signature); {
+		p, err := timePosition(client, baseURL, file, signature, i, rounds)
+		if err != nil {
+			return nil, err
+		}
+		signature[i] = p.best.b
+
+		// If the last byte didn't get the signature accepted, or this
+		// position's rejections aren't slower than the previous one's by
+		// at least half of what its winning byte stood out by, the
+		// previous byte was wrong.
+		wrongPrevious := false
+		if i > 0 && !p.accepted {
+			prev := positions[i-1]
+			wrongPrevious = i == len(signature)-1 ||
+				p.baseline-prev.baseline < (prev.best.median-prev.baseline)/2
+		}
+		if wrongPrevious {
+			if retriesLeft == 0 {
+				return nil, fmt.Errorf("couldn't recover signature; best guess %x", signature)
+			}
+			retriesLeft--
+			i--
+			continue
+		}
+
+		positions[i] = p
+		if progress != nil {
+			progress(signature, i)
+		}
+		if p.accepted {
+			return signature, nil
+		}
+		i++
+	}
+
+	return nil, fmt.Errorf("recovered signature %x was rejected", signature)
+}
+
+// Prints the signature recovered so far.
+func PrintProgress(signature []byte, i int) {
+	fmt.Printf("byte %2d: %x\n", i, signature[:i+1])
+}
+
+func Run() {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	file := "foo"
+
+	// The challenge sleeps for 50ms per byte, which makes recovering all
+	// 20 bytes take most of an hour. 5ms is usually well above the noise on
+	// a local server, so we time each guess only once, but now and then
+	// noise still picks a wrong byte. Expect RecoverMAC to back up and redo
+	// several bytes along the way, and the whole run to take five to ten
+	// minutes.
+	server := NewServer(key, 5*time.Millisecond)
+	defer server.Close()
+
+	start := time.Now()
+	signature, err := RecoverMAC(server.URL, file, 1, PrintProgress)
+	want := HMACSHA1(key, []byte(file))
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case hmac.Equal(signature, want):
+		fmt.Printf("got expected signature: %x (took %s)\n", signature, time.Since(start))
+	default:
+		fmt.Printf("got unexpected signature: %x (want %x)\n", signature, want)
+	}
+}
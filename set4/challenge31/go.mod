@@ -0,0 +1,3 @@
+module cryptopals/set4/challenge31
+
+go 1.15
@@ -0,0 +1,37 @@
+package challenge32
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"time"
+
+	"cryptopals/set4/challenge31"
+)
+
+func Run() {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	file := "foo"
+
+	// With only a couple of milliseconds per byte, a single request is about
+	// as likely to be slow because of noise as because of a matching byte.
+	// Taking the median of several requests per guess smooths that out.
+	server := challenge31.NewServer(key, 2*time.Millisecond)
+	defer server.Close()
+
+	start := time.Now()
+	signature, err := challenge31.RecoverMAC(server.URL, file, 5, challenge31.PrintProgress)
+	want := challenge31.HMACSHA1(key, []byte(file))
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case hmac.Equal(signature, want):
+		fmt.Printf("got expected signature: %x (took %s)\n", signature, time.Since(start))
+	default:
+		fmt.Printf("got unexpected signature: %x (want %x)\n", signature, want)
+	}
+}
@@ -0,0 +1,3 @@
+module cryptopals/set4/challenge32
+
+go 1.15
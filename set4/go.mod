@@ -0,0 +1,39 @@
+module cryptopals/set4
+
+go 1.15
+
+replace cryptopals/set4/challenge25 => ./challenge25
+
+require (
+	cryptopals/set1/challenge7 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set2/challenge9 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set2/challenge10 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge25 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge26 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge27 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge28 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge29 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge30 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge31 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge32 v0.0.0-00010101000000-000000000000 // indirect
+)
+
+replace cryptopals/set1/challenge7 => ../set1/challenge7
+
+replace cryptopals/set4/challenge26 => ./challenge26
+
+replace cryptopals/set4/challenge27 => ./challenge27
+
+replace cryptopals/set2/challenge9 => ../set2/challenge9
+
+replace cryptopals/set2/challenge10 => ../set2/challenge10
+
+replace cryptopals/set4/challenge28 => ./challenge28
+
+replace cryptopals/set4/challenge29 => ./challenge29
+
+replace cryptopals/set4/challenge30 => ./challenge30
+
+replace cryptopals/set4/challenge31 => ./challenge31
+
+replace cryptopals/set4/challenge32 => ./challenge32
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"cryptopals/set4/challenge25"
+	"cryptopals/set4/challenge26"
+	"cryptopals/set4/challenge27"
+	"cryptopals/set4/challenge28"
+	"cryptopals/set4/challenge29"
+	"cryptopals/set4/challenge30"
+	"cryptopals/set4/challenge31"
+	"cryptopals/set4/challenge32"
+)
+
+var timing = flag.Bool("timing", false, "also run challenges 31 and 32, which time a server byte by byte and take around 25 minutes together")
+
+func runChallenge(runFn func(), challengeNumber int) {
+	fmt.Printf("Challenge %d:\n", challengeNumber)
+	runFn()
+	fmt.Println()
+}
+
+func main() {
+	flag.Parse()
+
+	runChallenge(challenge25.Run, 25)
+	runChallenge(challenge26.Run, 26)
+	runChallenge(challenge27.Run, 27)
+	runChallenge(challenge28.Run, 28)
+	runChallenge(challenge29.Run, 29)
+	runChallenge(challenge30.Run, 30)
+	if *timing {
+		runChallenge(challenge31.Run, 31)
+		runChallenge(challenge32.Run, 32)
+	}
+}
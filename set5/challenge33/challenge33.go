@@ -0,0 +1,190 @@
+package challenge33
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set2/challenge10"
+	"cryptopals/set2/challenge9"
+)
+
+// Diffie-Hellman key exchange: Alice and Bob each pick a private number, send
+// each other g raised to it mod p, and raise what they receive to their own
+// private number. Both end up with g^(ab) mod p without ever sending it.
+// https://en.wikipedia.org/wiki/Diffie%E2%80%93Hellman_key_exchange
+
+// The 1536-bit MODP group's prime from RFC 3526, which the challenge calls
+// the NIST prime. Its generator is 2.
+const nistPrimeHex = "ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024" +
+	"e088a67cc74020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1" +
+	"4374fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7edee386" +
+	"bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf0598da48361c55d" +
+	"39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb9ed529077096966d670c3" +
+	"54e4abc9804f1746c08ca237327ffffffffffffffff"
+
+// Group is the public parameters both sides agree on: a prime modulus P and
+// a generator G.
+type Group struct {
+	P, G *big.Int
+}
+
+// Returns the 1536-bit group from RFC 3526.
+func NISTGroup() *Group {
+	p, ok := new(big.Int).SetString(nistPrimeHex, 16)
+	if !ok {
+		panic("challenge33: invalid NIST prime")
+	}
+	return &Group{P: p, G: big.NewInt(2)}
+}
+
+// Returns the toy group from the challenge, p = 37 and g = 5. It's small
+// enough to check by hand, and to brute-force.
+func SmallGroup() *Group {
+	return &Group{P: big.NewInt(37), G: big.NewInt(5)}
+}
+
+// KeyPair is one side's private number and the public value sent to the
+// other side.
+type KeyPair struct {
+	Private, Public *big.Int
+}
+
+// Picks a random private number in [1, P-2] and computes G^private mod P.
+func (g *Group) GenerateKeyPair() (*KeyPair, error) {
+	max := new(big.Int).Sub(g.P, big.NewInt(2))
+	private, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return nil, err
+	}
+	private.Add(private, big.NewInt(1))
+
+	return &KeyPair{
+		Private: private,
+		Public:  new(big.Int).Exp(g.G, private, g.P),
+	}, nil
+}
+
+// Returns otherPublic^private mod P, which is the same number on both sides.
+func (g *Group) SharedSecret(private, otherPublic *big.Int) *big.Int {
+	return new(big.Int).Exp(otherPublic, private, g.P)
+}
+
+// Turns the shared secret into an AES-128 key: the first 16 bytes of the
+// SHA-1 of its big-endian bytes.
+func SessionKey(s *big.Int) []byte {
+	digest := sha1.Sum(s.Bytes())
+	return digest[:aes.BlockSize]
+}
+
+// Encrypts the message with AES-CBC under the session key and a random IV,
+// padding it with PKCS#7 first. Returns the ciphertext with the IV appended.
+func EncryptMessage(key, message []byte) ([]byte, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	padded, err := challenge9.PadPKCS7(string(message), len(message)+aes.BlockSize-len(message)%aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := challenge10.EncryptAESWithCBC([]byte(padded), key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return append(ciphertext, iv...), nil
+}
+
+// Splits off the IV, decrypts, and removes the PKCS#7 padding.
+func DecryptMessage(key, data []byte) ([]byte, error) {
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("encrypted message must be whole blocks plus an IV")
+	}
+	ciphertext, iv := data[:len(data)-aes.BlockSize], data[len(data)-aes.BlockSize:]
+
+	padded, err := challenge10.DecryptAESWithCBC(ciphertext, key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return unpadPKCS7(padded)
+}
+
+// Removes PKCS#7 padding, checking that it's valid.
+func unpadPKCS7(padded []byte) ([]byte, error) {
+	if len(padded) == 0 {
+		return nil, fmt.Errorf("can't unpad an empty message")
+	}
+	padding := int(padded[len(padded)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(padded) {
+		return nil, fmt.Errorf("invalid PKCS#7 padding")
+	}
+	for _, b := range padded[len(padded)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid PKCS#7 padding")
+		}
+	}
+	return padded[:len(padded)-padding], nil
+}
+
+// Runs a key exchange in the group and checks that both sides get the same
+// shared secret.
+func checkKeyExchange(group *Group) (*big.Int, bool, error) {
+	alice, err := group.GenerateKeyPair()
+	if err != nil {
+		return nil, false, err
+	}
+	bob, err := group.GenerateKeyPair()
+	if err != nil {
+		return nil, false, err
+	}
+
+	aliceS := group.SharedSecret(alice.Private, bob.Public)
+	bobS := group.SharedSecret(bob.Private, alice.Public)
+	return aliceS, aliceS.Cmp(bobS) == 0, nil
+}
+
+func Run() {
+	for _, group := range []*Group{SmallGroup(), NISTGroup()} {
+		s, ok, err := checkKeyExchange(group)
+		switch {
+		case err != nil:
+			log.Fatal(err)
+		case ok:
+			fmt.Printf("checkKeyExchange passed for %d-bit p; s = %.20s...\n", group.P.BitLen(), s.Text(16))
+		case !ok:
+			log.Fatal(fmt.Errorf("checkKeyExchange failed for %d-bit p", group.P.BitLen()))
+		}
+	}
+
+	// The shared secret becomes a key for challenge 10's CBC mode.
+	group := NISTGroup()
+	alice, err := group.GenerateKeyPair()
+	if err != nil {
+		log.Fatal(err)
+	}
+	bob, err := group.GenerateKeyPair()
+	if err != nil {
+		log.Fatal(err)
+	}
+	aliceKey := SessionKey(group.SharedSecret(alice.Private, bob.Public))
+	bobKey := SessionKey(group.SharedSecret(bob.Private, alice.Public))
+
+	message := []byte("Hi Bob, it's Alice")
+	encrypted, err := EncryptMessage(aliceKey, message)
+	if err != nil {
+		log.Fatal(err)
+	}
+	decrypted, err := DecryptMessage(bobKey, encrypted)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case string(decrypted) == string(message):
+		fmt.Printf("got expected message: %q\n", decrypted)
+	default:
+		fmt.Printf("got unexpected message: %q\n", decrypted)
+	}
+}
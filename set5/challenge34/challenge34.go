@@ -0,0 +1,273 @@
+package challenge34
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge33"
+)
+
+// Alice, Bob and Mallory run in their own goroutines and talk over channels.
+// Each side of a connection is an Endpoint; Mallory holds one towards Alice
+// and one towards Bob, so everything between them goes through her.
+
+// Enough room that a participant never blocks on sending, even if the other
+// side has given up and stopped receiving.
+const pipeBuffer = 16
+
+var errClosed = errors.New("connection closed")
+
+// Endpoint is one side of a connection.
+type Endpoint struct {
+	send chan<- interface{}
+	recv <-chan interface{}
+}
+
+// Returns the two ends of a new connection.
+func Pipe() (Endpoint, Endpoint) {
+	aToB := make(chan interface{}, pipeBuffer)
+	bToA := make(chan interface{}, pipeBuffer)
+	return Endpoint{send: aToB, recv: bToA}, Endpoint{send: bToA, recv: aToB}
+}
+
+func (e Endpoint) Send(message interface{}) {
+	e.send <- message
+}
+
+// Waits for the next message. Returns an error if the other side has closed
+// the connection.
+func (e Endpoint) Recv() (interface{}, error) {
+	message, ok := <-e.recv
+	if !ok {
+		return nil, errClosed
+	}
+	return message, nil
+}
+
+// Tells the other side that nothing more is coming.
+func (e Endpoint) Close() {
+	close(e.send)
+}
+
+// Runs each function in its own goroutine, waits for all of them, and
+// returns the first error.
+func RunAll(fns ...func() error) error {
+	errs := make(chan error, len(fns))
+	for _, fn := range fns {
+		go func(fn func() error) {
+			errs <- fn()
+		}(fn)
+	}
+
+	var firstErr error
+	for range fns {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// The protocol's messages.
+type groupMessage struct {
+	p, g, a *big.Int
+}
+
+// Bob's public key. Challenge 35 reuses this and EncryptedMessage, since it
+// only changes how the group is agreed on.
+type PublicKeyMessage struct {
+	Key *big.Int
+}
+
+// A message encrypted with challenge33.EncryptMessage.
+type EncryptedMessage struct {
+	Data []byte
+}
+
+// Each of these waits for a message and checks that it's the expected kind.
+// RecvPublicKey and RecvEncrypted are exported for challenge 35.
+
+func recvGroup(ep Endpoint) (groupMessage, error) {
+	m, err := ep.Recv()
+	if err != nil {
+		return groupMessage{}, err
+	}
+	msg, ok := m.(groupMessage)
+	if !ok {
+		return groupMessage{}, fmt.Errorf("expected group message, got %T", m)
+	}
+	return msg, nil
+}
+
+func RecvPublicKey(ep Endpoint) (PublicKeyMessage, error) {
+	m, err := ep.Recv()
+	if err != nil {
+		return PublicKeyMessage{}, err
+	}
+	msg, ok := m.(PublicKeyMessage)
+	if !ok {
+		return PublicKeyMessage{}, fmt.Errorf("expected public key message, got %T", m)
+	}
+	return msg, nil
+}
+
+func RecvEncrypted(ep Endpoint) (EncryptedMessage, error) {
+	m, err := ep.Recv()
+	if err != nil {
+		return EncryptedMessage{}, err
+	}
+	msg, ok := m.(EncryptedMessage)
+	if !ok {
+		return EncryptedMessage{}, fmt.Errorf("expected encrypted message, got %T", m)
+	}
+	return msg, nil
+}
+
+// Alice picks the group, sends it with her public key, then sends Bob an
+// encrypted message and checks that he echoes it back.
+func alice(ep Endpoint, group *challenge33.Group, message []byte) error {
+	defer ep.Close()
+
+	keys, err := group.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	ep.Send(groupMessage{p: group.P, g: group.G, a: keys.Public})
+
+	reply, err := RecvPublicKey(ep)
+	if err != nil {
+		return err
+	}
+	key := challenge33.SessionKey(group.SharedSecret(keys.Private, reply.Key))
+
+	encrypted, err := challenge33.EncryptMessage(key, message)
+	if err != nil {
+		return err
+	}
+	ep.Send(EncryptedMessage{Data: encrypted})
+
+	echo, err := RecvEncrypted(ep)
+	if err != nil {
+		return err
+	}
+	echoed, err := challenge33.DecryptMessage(key, echo.Data)
+	if err != nil {
+		return err
+	}
+	if string(echoed) != string(message) {
+		return fmt.Errorf("alice: Bob echoed %q, want %q", echoed, message)
+	}
+	return nil
+}
+
+// Bob uses whatever group Alice sends, then decrypts her message and sends
+// it back re-encrypted under a new IV.
+func bob(ep Endpoint) error {
+	defer ep.Close()
+
+	hello, err := recvGroup(ep)
+	if err != nil {
+		return err
+	}
+	group := &challenge33.Group{P: hello.p, G: hello.g}
+	keys, err := group.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	ep.Send(PublicKeyMessage{Key: keys.Public})
+	key := challenge33.SessionKey(group.SharedSecret(keys.Private, hello.a))
+
+	msg, err := RecvEncrypted(ep)
+	if err != nil {
+		return err
+	}
+	plaintext, err := challenge33.DecryptMessage(key, msg.Data)
+	if err != nil {
+		return err
+	}
+	encrypted, err := challenge33.EncryptMessage(key, plaintext)
+	if err != nil {
+		return err
+	}
+	ep.Send(EncryptedMessage{Data: encrypted})
+	return nil
+}
+
+// Mallory swaps both public keys for p. Alice computes p^a mod p and Bob
+// computes p^b mod p, which are both 0 -- so Mallory knows the session key
+// without knowing either private key. She decrypts everything she relays
+// and returns the plaintexts.
+func mallory(toAlice, toBob Endpoint) ([][]byte, error) {
+	defer toAlice.Close()
+	defer toBob.Close()
+
+	hello, err := recvGroup(toAlice)
+	if err != nil {
+		return nil, err
+	}
+	toBob.Send(groupMessage{p: hello.p, g: hello.g, a: hello.p})
+
+	if _, err := RecvPublicKey(toBob); err != nil {
+		return nil, err
+	}
+	toAlice.Send(PublicKeyMessage{Key: hello.p})
+
+	key := challenge33.SessionKey(big.NewInt(0))
+	var decrypted [][]byte
+	for _, hop := range []struct{ from, to Endpoint }{{toAlice, toBob}, {toBob, toAlice}} {
+		msg, err := RecvEncrypted(hop.from)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := challenge33.DecryptMessage(key, msg.Data)
+		if err != nil {
+			return nil, err
+		}
+		decrypted = append(decrypted, plaintext)
+		hop.to.Send(msg)
+	}
+	return decrypted, nil
+}
+
+func Run() {
+	group := challenge33.NISTGroup()
+	message := []byte("Meet me at the usual place")
+
+	// Without Mallory, Alice and Bob just talk.
+	aliceEnd, bobEnd := Pipe()
+	err := RunAll(
+		func() error { return alice(aliceEnd, group, message) },
+		func() error { return bob(bobEnd) },
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("echo without Mallory passed")
+
+	// With Mallory in the middle, the protocol still works as far as Alice
+	// and Bob can tell, but Mallory reads both messages.
+	aliceEnd, malloryAliceEnd := Pipe()
+	malloryBobEnd, bobEnd := Pipe()
+	var decrypted [][]byte
+	err = RunAll(
+		func() error { return alice(aliceEnd, group, message) },
+		func() error { return bob(bobEnd) },
+		func() error {
+			var err error
+			decrypted, err = mallory(malloryAliceEnd, malloryBobEnd)
+			return err
+		},
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range decrypted {
+		if string(d) == string(message) {
+			fmt.Printf("got expected message: %q\n", d)
+		} else {
+			fmt.Printf("got unexpected message: %q\n", d)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module cryptopals/set5/challenge34
+
+go 1.15
@@ -0,0 +1,262 @@
+package challenge35
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge33"
+	"cryptopals/set5/challenge34"
+)
+
+// Like challenge 34, but Alice and Bob negotiate the group before exchanging
+// public keys:
+// - Alice sends p and g
+// - Bob acknowledges the group, and both use the group he acknowledged
+// - Alice sends A, Bob sends B
+// - Alice sends an encrypted message, and Bob echoes it back
+// Mallory can't swap the public keys any more without being noticed, but she
+// can change g during the negotiation.
+
+type negotiateMessage struct {
+	p, g *big.Int
+}
+
+type ackMessage struct {
+	p, g *big.Int
+}
+
+func recvNegotiate(ep challenge34.Endpoint) (negotiateMessage, error) {
+	m, err := ep.Recv()
+	if err != nil {
+		return negotiateMessage{}, err
+	}
+	msg, ok := m.(negotiateMessage)
+	if !ok {
+		return negotiateMessage{}, fmt.Errorf("expected negotiate message, got %T", m)
+	}
+	return msg, nil
+}
+
+func recvAck(ep challenge34.Endpoint) (ackMessage, error) {
+	m, err := ep.Recv()
+	if err != nil {
+		return ackMessage{}, err
+	}
+	msg, ok := m.(ackMessage)
+	if !ok {
+		return ackMessage{}, fmt.Errorf("expected ack message, got %T", m)
+	}
+	return msg, nil
+}
+
+func alice(ep challenge34.Endpoint, group *challenge33.Group, message []byte) error {
+	defer ep.Close()
+
+	ep.Send(negotiateMessage{p: group.P, g: group.G})
+	ack, err := recvAck(ep)
+	if err != nil {
+		return err
+	}
+	group = &challenge33.Group{P: ack.p, G: ack.g}
+
+	keys, err := group.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	ep.Send(challenge34.PublicKeyMessage{Key: keys.Public})
+	reply, err := challenge34.RecvPublicKey(ep)
+	if err != nil {
+		return err
+	}
+	key := challenge33.SessionKey(group.SharedSecret(keys.Private, reply.Key))
+
+	encrypted, err := challenge33.EncryptMessage(key, message)
+	if err != nil {
+		return err
+	}
+	ep.Send(challenge34.EncryptedMessage{Data: encrypted})
+
+	echo, err := challenge34.RecvEncrypted(ep)
+	if err != nil {
+		return err
+	}
+	echoed, err := challenge33.DecryptMessage(key, echo.Data)
+	if err != nil {
+		return err
+	}
+	if string(echoed) != string(message) {
+		return fmt.Errorf("alice: Bob echoed %q, want %q", echoed, message)
+	}
+	return nil
+}
+
+func bob(ep challenge34.Endpoint) error {
+	defer ep.Close()
+
+	negotiate, err := recvNegotiate(ep)
+	if err != nil {
+		return err
+	}
+	group := &challenge33.Group{P: negotiate.p, G: negotiate.g}
+	ep.Send(ackMessage{p: group.P, g: group.G})
+
+	other, err := challenge34.RecvPublicKey(ep)
+	if err != nil {
+		return err
+	}
+	keys, err := group.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	ep.Send(challenge34.PublicKeyMessage{Key: keys.Public})
+	key := challenge33.SessionKey(group.SharedSecret(keys.Private, other.Key))
+
+	msg, err := challenge34.RecvEncrypted(ep)
+	if err != nil {
+		return err
+	}
+	plaintext, err := challenge33.DecryptMessage(key, msg.Data)
+	if err != nil {
+		return err
+	}
+	encrypted, err := challenge33.EncryptMessage(key, plaintext)
+	if err != nil {
+		return err
+	}
+	ep.Send(challenge34.EncryptedMessage{Data: encrypted})
+	return nil
+}
+
+// Returns the shared secrets that are possible when both sides use the
+// generator g (mod p). Every power of 1 is 1, and every power of p is 0 mod
+// p. p - 1 is -1 mod p, so its powers are 1 or p - 1, depending on whether
+// the exponent is even or odd.
+func candidateSecrets(p, g *big.Int) []*big.Int {
+	one := big.NewInt(1)
+	pMinusOne := new(big.Int).Sub(p, one)
+	switch {
+	case g.Cmp(one) == 0:
+		return []*big.Int{one}
+	case g.Cmp(p) == 0:
+		return []*big.Int{big.NewInt(0)}
+	case g.Cmp(pMinusOne) == 0:
+		return []*big.Int{one, pMinusOne}
+	}
+	return nil
+}
+
+// Finds the key for a message and its echo, which were encrypted under the
+// same key, and returns both plaintexts. A wrong key only rarely gets valid
+// padding on one message, and practically never on both with the same
+// plaintext, so exactly one candidate should pass.
+func decryptWithCandidates(sent, echo []byte, candidates []*big.Int) ([][]byte, error) {
+	var decrypted [][]byte
+	for _, s := range candidates {
+		key := challenge33.SessionKey(s)
+		plaintext, err := challenge33.DecryptMessage(key, sent)
+		if err != nil {
+			continue
+		}
+		echoed, err := challenge33.DecryptMessage(key, echo)
+		if err != nil || string(echoed) != string(plaintext) {
+			continue
+		}
+		if decrypted != nil {
+			return nil, fmt.Errorf("more than one candidate secret decrypts the messages")
+		}
+		decrypted = [][]byte{plaintext, echoed}
+	}
+	if decrypted == nil {
+		return nil, fmt.Errorf("no candidate secret decrypts the messages")
+	}
+	return decrypted, nil
+}
+
+// Mallory replaces g with maliciousG in the negotiation. Bob acknowledges the
+// group he was sent, so both sides end up using it, and the shared secret
+// is one of a couple of values she can predict.
+func mallory(toAlice, toBob challenge34.Endpoint, maliciousG func(p *big.Int) *big.Int) ([][]byte, error) {
+	defer toAlice.Close()
+	defer toBob.Close()
+
+	negotiate, err := recvNegotiate(toAlice)
+	if err != nil {
+		return nil, err
+	}
+	g := maliciousG(negotiate.p)
+	toBob.Send(negotiateMessage{p: negotiate.p, g: g})
+
+	ack, err := recvAck(toBob)
+	if err != nil {
+		return nil, err
+	}
+	toAlice.Send(ack)
+
+	// The public keys pass through untouched.
+	a, err := challenge34.RecvPublicKey(toAlice)
+	if err != nil {
+		return nil, err
+	}
+	toBob.Send(a)
+	b, err := challenge34.RecvPublicKey(toBob)
+	if err != nil {
+		return nil, err
+	}
+	toAlice.Send(b)
+
+	// Both messages are passed on before we try to decrypt them, since we
+	// need the echo to tell which candidate is right.
+	sent, err := challenge34.RecvEncrypted(toAlice)
+	if err != nil {
+		return nil, err
+	}
+	toBob.Send(sent)
+	echo, err := challenge34.RecvEncrypted(toBob)
+	if err != nil {
+		return nil, err
+	}
+	toAlice.Send(echo)
+
+	return decryptWithCandidates(sent.Data, echo.Data, candidateSecrets(negotiate.p, g))
+}
+
+func Run() {
+	group := challenge33.NISTGroup()
+	message := []byte("Meet me at the usual place")
+
+	attacks := []struct {
+		name string
+		g    func(p *big.Int) *big.Int
+	}{
+		{"g = 1", func(p *big.Int) *big.Int { return big.NewInt(1) }},
+		{"g = p", func(p *big.Int) *big.Int { return new(big.Int).Set(p) }},
+		{"g = p - 1", func(p *big.Int) *big.Int { return new(big.Int).Sub(p, big.NewInt(1)) }},
+	}
+
+	for _, attack := range attacks {
+		aliceEnd, malloryAliceEnd := challenge34.Pipe()
+		malloryBobEnd, bobEnd := challenge34.Pipe()
+		var decrypted [][]byte
+		err := challenge34.RunAll(
+			func() error { return alice(aliceEnd, group, message) },
+			func() error { return bob(bobEnd) },
+			func() error {
+				var err error
+				decrypted, err = mallory(malloryAliceEnd, malloryBobEnd, attack.g)
+				return err
+			},
+		)
+		if err != nil {
+			log.Fatal(fmt.Errorf("%s: %v", attack.name, err))
+		}
+
+		for _, d := range decrypted {
+			if string(d) == string(message) {
+				fmt.Printf("%s: got expected message: %q\n", attack.name, d)
+			} else {
+				fmt.Printf("%s: got unexpected message: %q\n", attack.name, d)
+			}
+		}
+	}
+}
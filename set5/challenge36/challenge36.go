@@ -0,0 +1,281 @@
+package challenge36
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+
+	"cryptopals/set5/challenge33"
+)
+
+// Secure Remote Password (SRP-6a). The server stores a verifier derived from
+// the password instead of the password itself, and the client proves it
+// knows the password without sending it.
+// http://srp.stanford.edu/design.html
+
+// Params are the values both sides agree on ahead of time.
+type Params struct {
+	// A large safe prime, and a generator mod N.
+	N, G *big.Int
+	// The multiplier that stops the server's public value from being
+	// predictable from the verifier. SRP-6a derives it as k = H(N | PAD(g));
+	// the challenge's k = 3 is the older SRP-6's.
+	K *big.Int
+}
+
+// Returns the parameters for the NIST prime from challenge 33 and g = 2, with
+// k = H(N | PAD(g)) as SRP-6a has it.
+func DefaultParams() *Params {
+	group := challenge33.NISTGroup()
+	return &Params{N: group.P, G: group.G, K: Multiplier(group.P, group.G)}
+}
+
+// Returns SRP-6a's multiplier k = H(N | PAD(g)), where PAD(g) is g padded
+// with zero bytes in front to the length of N.
+func Multiplier(n, g *big.Int) *big.Int {
+	nBytes := n.Bytes()
+	padded := make([]byte, len(nBytes))
+	gBytes := g.Bytes()
+	copy(padded[len(padded)-len(gBytes):], gBytes)
+	return hashToInt(nBytes, padded)
+}
+
+// Server is what an SRP client talks to. The in-memory server below
+// implements it; a network server could too.
+type Server interface {
+	// Stores the salt and verifier for a new user.
+	Register(email string, salt []byte, verifier *big.Int) error
+	// Starts a login with the client's public value A. Returns the user's
+	// salt and the server's public value B.
+	StartLogin(email string, a *big.Int) ([]byte, *big.Int, error)
+	// Finishes the login started for email. Returns ErrLoginFailed if the
+	// proof is wrong.
+	FinishLogin(email string, proof []byte) error
+}
+
+// Returned for a wrong password or unknown user. StartLogin turns away an
+// unknown user straight away, so this server does give away which emails are
+// registered.
+var ErrLoginFailed = errors.New("login failed")
+
+// Returns the SHA-256 of the concatenated inputs as an integer.
+func hashToInt(inputs ...[]byte) *big.Int {
+	h := sha256.New()
+	for _, in := range inputs {
+		h.Write(in)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// Returns a random integer in [1, n-1].
+func randomExponent(n *big.Int) (*big.Int, error) {
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return r.Add(r, big.NewInt(1)), nil
+}
+
+// Derives the private key x from the salt and password: x = H(salt | password).
+func PrivateKey(salt []byte, password string) *big.Int {
+	return hashToInt(salt, []byte(password))
+}
+
+// Scrambles the two public values together: u = H(A | B).
+func Scrambler(a, b *big.Int) *big.Int {
+	return hashToInt(a.Bytes(), b.Bytes())
+}
+
+// Turns the shared secret S into the session key K = H(S).
+func SessionKey(s *big.Int) []byte {
+	digest := sha256.Sum256(s.Bytes())
+	return digest[:]
+}
+
+// The proof of the session key that the client sends: HMAC-SHA256(K, salt).
+func Proof(key, salt []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(salt)
+	return mac.Sum(nil)
+}
+
+// What the server keeps about a registered user.
+type user struct {
+	salt     []byte
+	verifier *big.Int
+}
+
+// What the server remembers between StartLogin and FinishLogin.
+type session struct {
+	salt []byte
+	key  []byte
+}
+
+// An SRP server that keeps its users in memory.
+type memoryServer struct {
+	params *Params
+
+	mu       sync.Mutex
+	users    map[string]user
+	sessions map[string]session
+}
+
+// Returns an SRP server with no users yet.
+func NewServer(params *Params) Server {
+	return &memoryServer{
+		params:   params,
+		users:    make(map[string]user),
+		sessions: make(map[string]session),
+	}
+}
+
+func (s *memoryServer) Register(email string, salt []byte, verifier *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[email]; ok {
+		return fmt.Errorf("%s is already registered", email)
+	}
+	s.users[email] = user{salt: salt, verifier: verifier}
+	return nil
+}
+
+// B = kv + g^b mod N, and the server's side of the shared secret is
+// S = (A * v^u)^b mod N.
+//
+// A real SRP server would refuse an A that's 0 mod N. This one doesn't, which
+// is what challenge 37 takes advantage of.
+func (s *memoryServer) StartLogin(email string, a *big.Int) ([]byte, *big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return nil, nil, ErrLoginFailed
+	}
+	p := s.params
+
+	b, err := randomExponent(p.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	bPublic := new(big.Int).Mul(p.K, u.verifier)
+	bPublic.Add(bPublic, new(big.Int).Exp(p.G, b, p.N))
+	bPublic.Mod(bPublic, p.N)
+
+	scrambler := Scrambler(a, bPublic)
+	secret := new(big.Int).Exp(u.verifier, scrambler, p.N)
+	secret.Mul(secret, a)
+	secret.Exp(secret, b, p.N)
+
+	s.sessions[email] = session{salt: u.salt, key: SessionKey(secret)}
+	return u.salt, bPublic, nil
+}
+
+func (s *memoryServer) FinishLogin(email string, proof []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[email]
+	if !ok {
+		return ErrLoginFailed
+	}
+	// A session only gets one try.
+	delete(s.sessions, email)
+
+	if !hmac.Equal(proof, Proof(sess.key, sess.salt)) {
+		return ErrLoginFailed
+	}
+	return nil
+}
+
+// An SRP client, which registers and logs in with a server.
+type Client struct {
+	params *Params
+	server Server
+}
+
+// Returns a client which talks to the server.
+func NewClient(params *Params, server Server) *Client {
+	return &Client{params: params, server: server}
+}
+
+// Picks a random salt, and registers the verifier v = g^x mod N with the
+// server. The password itself never leaves the client.
+func (c *Client) Register(email, password string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	x := PrivateKey(salt, password)
+	verifier := new(big.Int).Exp(c.params.G, x, c.params.N)
+	return c.server.Register(email, salt, verifier)
+}
+
+// Logs in as email. The client's side of the shared secret is
+// S = (B - k * g^x)^(a + u * x) mod N, which equals the server's if the
+// password is right.
+func (c *Client) Login(email, password string) error {
+	p := c.params
+
+	a, err := randomExponent(p.N)
+	if err != nil {
+		return err
+	}
+	aPublic := new(big.Int).Exp(p.G, a, p.N)
+
+	salt, bPublic, err := c.server.StartLogin(email, aPublic)
+	if err != nil {
+		return err
+	}
+
+	scrambler := Scrambler(aPublic, bPublic)
+	x := PrivateKey(salt, password)
+
+	base := new(big.Int).Exp(p.G, x, p.N)
+	base.Mul(base, p.K)
+	base.Sub(bPublic, base)
+	base.Mod(base, p.N)
+
+	exponent := new(big.Int).Mul(scrambler, x)
+	exponent.Add(exponent, a)
+
+	secret := new(big.Int).Exp(base, exponent, p.N)
+	return c.server.FinishLogin(email, Proof(SessionKey(secret), salt))
+}
+
+func Run() {
+	params := DefaultParams()
+	server := NewServer(params)
+	client := NewClient(params, server)
+
+	email, password := "alice@example.com", "correct horse battery staple"
+	if err := client.Register(email, password); err != nil {
+		log.Fatal(err)
+	}
+
+	logins := []struct {
+		name, email, password string
+		wantOK                bool
+	}{
+		{"right password", email, password, true},
+		{"wrong password", email, "Tr0ub4dor&3", false},
+		{"unknown user", "mallory@example.com", password, false},
+	}
+	for _, l := range logins {
+		err := client.Login(l.email, l.password)
+		switch {
+		case err != nil && !errors.Is(err, ErrLoginFailed):
+			log.Fatal(err)
+		case (err == nil) == l.wantOK:
+			fmt.Printf("%s: got expected result (logged in: %t)\n", l.name, err == nil)
+		default:
+			fmt.Printf("%s: got unexpected result (logged in: %t)\n", l.name, err == nil)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module cryptopals/set5/challenge36
+
+go 1.15
@@ -0,0 +1,48 @@
+package challenge37
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge36"
+)
+
+// The server computes its side of the shared secret as S = (A * v^u)^b mod N.
+// If A is 0 mod N, so is S, whatever the verifier is -- so the attacker knows
+// the session key is H(0) without knowing the password.
+func loginWithoutPassword(server challenge36.Server, email string, a *big.Int) error {
+	salt, _, err := server.StartLogin(email, a)
+	if err != nil {
+		return err
+	}
+	key := challenge36.SessionKey(big.NewInt(0))
+	return server.FinishLogin(email, challenge36.Proof(key, salt))
+}
+
+func Run() {
+	params := challenge36.DefaultParams()
+	server := challenge36.NewServer(params)
+
+	// Register a user whose password the attacker doesn't know.
+	email := "alice@example.com"
+	if err := challenge36.NewClient(params, server).Register(email, "a password nobody will guess"); err != nil {
+		log.Fatal(err)
+	}
+
+	attacks := []struct {
+		name string
+		a    *big.Int
+	}{
+		{"A = 0", big.NewInt(0)},
+		{"A = N", new(big.Int).Set(params.N)},
+		{"A = 2N", new(big.Int).Mul(params.N, big.NewInt(2))},
+	}
+	for _, attack := range attacks {
+		if err := loginWithoutPassword(server, email, attack.a); err != nil {
+			fmt.Printf("%s: got unexpected result: %v\n", attack.name, err)
+			continue
+		}
+		fmt.Printf("%s: got expected result: logged in without the password\n", attack.name)
+	}
+}
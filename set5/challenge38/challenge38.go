@@ -0,0 +1,273 @@
+package challenge38
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cryptopals/set5/challenge36"
+)
+
+// Simplified SRP: B = g^b mod N doesn't involve the verifier, and u is a
+// random number chosen by the server rather than a hash of A and B.
+//
+//   Client: A = g^a mod N
+//   Server: salt, B = g^b mod N, u = random 128-bit number
+//   Client: S = B^(a + ux) mod N
+//   Server: S = (A * v^u)^b mod N
+//   Client: HMAC-SHA256(K, salt), where K = H(S)
+//
+// Since nothing the server sends depends on the verifier, a fake server can
+// send whatever b, u and salt it likes. The client's proof then lets it
+// test password guesses offline.
+
+// What the simplified SRP client talks to.
+type server interface {
+	StartLogin(email string, a *big.Int) (salt []byte, b, u *big.Int, err error)
+	FinishLogin(email string, proof []byte) error
+}
+
+// Logs in to the server as email.
+func login(params *challenge36.Params, srv server, email, password string) error {
+	a, err := rand.Int(rand.Reader, params.N)
+	if err != nil {
+		return err
+	}
+	aPublic := new(big.Int).Exp(params.G, a, params.N)
+
+	salt, bPublic, u, err := srv.StartLogin(email, aPublic)
+	if err != nil {
+		return err
+	}
+
+	x := challenge36.PrivateKey(salt, password)
+	exponent := new(big.Int).Mul(u, x)
+	exponent.Add(exponent, a)
+	secret := new(big.Int).Exp(bPublic, exponent, params.N)
+
+	return srv.FinishLogin(email, challenge36.Proof(challenge36.SessionKey(secret), salt))
+}
+
+// The server side of simplified SRP, for a single user.
+type honestServer struct {
+	params   *challenge36.Params
+	email    string
+	salt     []byte
+	verifier *big.Int
+
+	// The session key for the login in progress.
+	key []byte
+}
+
+func newHonestServer(params *challenge36.Params, email, password string) (*honestServer, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	x := challenge36.PrivateKey(salt, password)
+	return &honestServer{
+		params:   params,
+		email:    email,
+		salt:     salt,
+		verifier: new(big.Int).Exp(params.G, x, params.N),
+	}, nil
+}
+
+func (s *honestServer) StartLogin(email string, a *big.Int) ([]byte, *big.Int, *big.Int, error) {
+	if email != s.email {
+		return nil, nil, nil, challenge36.ErrLoginFailed
+	}
+	p := s.params
+
+	b, err := rand.Int(rand.Reader, p.N)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	u, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	secret := new(big.Int).Exp(s.verifier, u, p.N)
+	secret.Mul(secret, a)
+	secret.Exp(secret, b, p.N)
+	s.key = challenge36.SessionKey(secret)
+
+	return s.salt, new(big.Int).Exp(p.G, b, p.N), u, nil
+}
+
+func (s *honestServer) FinishLogin(email string, proof []byte) error {
+	if s.key == nil || !hmac.Equal(proof, challenge36.Proof(s.key, s.salt)) {
+		return challenge36.ErrLoginFailed
+	}
+	return nil
+}
+
+// Everything the fake server needs to test password guesses offline.
+type capture struct {
+	a     *big.Int
+	salt  []byte
+	proof []byte
+}
+
+// A fake server which picks b = 1, u = 1 and an empty salt, so that the
+// client's S is just A * v mod N, where v = g^H(password). It records the
+// client's proof and accepts it, so the client doesn't suspect anything.
+type mitmServer struct {
+	params *challenge36.Params
+	capture
+}
+
+func (s *mitmServer) StartLogin(email string, a *big.Int) ([]byte, *big.Int, *big.Int, error) {
+	s.a = a
+	s.salt = []byte{}
+	// B = g^1.
+	return s.salt, new(big.Int).Set(s.params.G), big.NewInt(1), nil
+}
+
+func (s *mitmServer) FinishLogin(email string, proof []byte) error {
+	s.proof = proof
+	return nil
+}
+
+// Reports whether the password produces the captured proof.
+func tryPassword(params *challenge36.Params, c capture, password string) bool {
+	x := challenge36.PrivateKey(c.salt, password)
+	secret := new(big.Int).Exp(params.G, x, params.N)
+	secret.Mul(secret, c.a)
+	secret.Mod(secret, params.N)
+	return hmac.Equal(c.proof, challenge36.Proof(challenge36.SessionKey(secret), c.salt))
+}
+
+// How a dictionary attack went.
+type crackResult struct {
+	password string
+	found    bool
+	guesses  int64
+	elapsed  time.Duration
+}
+
+func (r crackResult) guessesPerSecond() float64 {
+	return float64(r.guesses) / r.elapsed.Seconds()
+}
+
+// Tries each word against the captured handshake, spreading the words across
+// workers goroutines. Stops as soon as any worker finds the password.
+func crackPassword(params *challenge36.Params, c capture, words []string, workers int) crackResult {
+	start := time.Now()
+
+	var (
+		guesses  int64
+		once     sync.Once
+		password string
+		found    = make(chan struct{})
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Each worker takes every workers-th word, starting at w.
+			for i := w; i < len(words); i += workers {
+				select {
+				case <-found:
+					return
+				default:
+				}
+
+				atomic.AddInt64(&guesses, 1)
+				if tryPassword(params, c, words[i]) {
+					once.Do(func() {
+						password = words[i]
+						close(found)
+					})
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	result := crackResult{guesses: guesses, elapsed: time.Since(start)}
+	select {
+	case <-found:
+		result.password = password
+		result.found = true
+	default:
+	}
+	return result
+}
+
+// Reads the word list, one word per line.
+func readWords(path string) ([]string, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	for _, line := range strings.Split(string(raw), "\n") {
+		if word := strings.TrimSpace(line); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words, nil
+}
+
+func Run() {
+	params := challenge36.DefaultParams()
+	email := "alice@example.com"
+
+	words, err := readWords("/home/swalters4925/cryptopals/set5/challenge38/data.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Alice's password is somewhere in the word list.
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+	if err != nil {
+		log.Fatal(err)
+	}
+	password := words[index.Int64()]
+
+	// Against the real server, the protocol works.
+	honest, err := newHonestServer(params, email, password)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := login(params, honest, email, password); err != nil {
+		log.Fatal(err)
+	}
+	if err := login(params, honest, email, password+"!"); !errors.Is(err, challenge36.ErrLoginFailed) {
+		log.Fatal(fmt.Errorf("login with the wrong password: got %v, want %v", err, challenge36.ErrLoginFailed))
+	}
+	fmt.Println("simplified SRP login passed")
+
+	// Alice logs in to Mallory's server instead, and Mallory cracks her
+	// password offline.
+	mitm := &mitmServer{params: params}
+	if err := login(params, mitm, email, password); err != nil {
+		log.Fatal(err)
+	}
+
+	workers := runtime.NumCPU()
+	result := crackPassword(params, mitm.capture, words, workers)
+	switch {
+	case !result.found:
+		fmt.Printf("got unexpected result: password not in word list (%d guesses)\n", result.guesses)
+	case result.password == password:
+		fmt.Printf("got expected password: %q\n", result.password)
+	default:
+		fmt.Printf("got unexpected password: %q (want %q)\n", result.password, password)
+	}
+	fmt.Printf("%d guesses in %s on %d workers: %.0f guesses/second\n",
+		result.guesses, result.elapsed.Round(time.Millisecond), workers, result.guessesPerSecond())
+}
@@ -0,0 +1,225 @@
+package challenge39
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+)
+
+// Textbook RSA: no padding, so encryption is just m^e mod n. That's insecure
+// in all sorts of ways, which is the point -- the attacks in the rest of the
+// challenges are built on these raw operations.
+// https://en.wikipedia.org/wiki/RSA_(cryptosystem)
+
+// Returns the x for which a*x = 1 mod m, using the extended Euclidean
+// algorithm. Returns an error if a and m aren't coprime, in which case there's
+// no such x.
+//
+// The extended algorithm keeps track of how to write each remainder as a
+// combination of a and m. When the remainder reaches gcd(a, m) = 1, the
+// coefficient of a is the inverse.
+func InvMod(a, m *big.Int) (*big.Int, error) {
+	if m.Sign() <= 0 {
+		return nil, fmt.Errorf("modulus must be positive, got %s", m)
+	}
+
+	// Invariant: r = s*a (mod m), for both (oldR, oldS) and (r, s).
+	oldR, r := new(big.Int).Mod(a, m), new(big.Int).Set(m)
+	oldS, s := big.NewInt(1), big.NewInt(0)
+	quotient := new(big.Int)
+	for r.Sign() != 0 {
+		quotient.Div(oldR, r)
+		oldR, r = r, new(big.Int).Sub(oldR, new(big.Int).Mul(quotient, r))
+		oldS, s = s, new(big.Int).Sub(oldS, new(big.Int).Mul(quotient, s))
+	}
+
+	if oldR.Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("%s has no inverse mod %s", a, m)
+	}
+	return oldS.Mod(oldS, m), nil
+}
+
+// An RSA public key: the modulus n and the public exponent e.
+type PublicKey struct {
+	N, E *big.Int
+}
+
+// An RSA private key: the public key, and the private exponent d.
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+}
+
+// Returns the public exponent the challenge uses, e = 3. Each call returns a
+// new copy, so callers can't change it for each other.
+func DefaultE() *big.Int {
+	return big.NewInt(3)
+}
+
+// How many pairs of primes GenerateKey tries before giving up.
+const maxKeyAttempts = 1000
+
+// Generates a key whose modulus has the given number of bits, using the
+// public exponent e. The primes are regenerated until e is invertible mod
+// (p-1)(q-1). For e = 3 that needs p and q to both be 2 mod 3, which rules
+// out about three quarters of them.
+//
+// (p-1)(q-1) is always even, so an even e is never invertible; e has to be
+// odd and at least 3. An e that shares a factor with nearly every candidate
+// would still keep us going for a long time, so we give up after
+// maxKeyAttempts tries.
+func GenerateKey(bits int, e *big.Int) (*PrivateKey, error) {
+	if e.Cmp(big.NewInt(3)) < 0 || e.Bit(0) == 0 {
+		return nil, fmt.Errorf("public exponent must be odd and at least 3, got %v", e)
+	}
+
+	one := big.NewInt(1)
+	for attempt := 0; attempt < maxKeyAttempts; attempt++ {
+		p, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || n.BitLen() != bits {
+			continue
+		}
+
+		et := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d, err := InvMod(e, et)
+		if err != nil {
+			continue
+		}
+		return &PrivateKey{
+			PublicKey: PublicKey{N: n, E: new(big.Int).Set(e)},
+			D:         d,
+		}, nil
+	}
+	return nil, fmt.Errorf("no %d-bit key with e = %v after %d tries", bits, e, maxKeyAttempts)
+}
+
+// Returns the modulus' length in bytes.
+func (pub *PublicKey) Size() int {
+	return (pub.N.BitLen() + 7) / 8
+}
+
+// Returns b with zero bytes added in front to make it k bytes long, the way a
+// number has to be laid out to fill a block the size of the modulus.
+func LeftPad(b []byte, k int) []byte {
+	if len(b) >= k {
+		return b
+	}
+	return append(make([]byte, k-len(b)), b...)
+}
+
+// Returns m^e mod n.
+func (pub *PublicKey) Encrypt(m *big.Int) *big.Int {
+	return new(big.Int).Exp(m, pub.E, pub.N)
+}
+
+// Returns c^d mod n.
+func (priv *PrivateKey) Decrypt(c *big.Int) *big.Int {
+	return new(big.Int).Exp(c, priv.D, priv.N)
+}
+
+// Encrypts the message as a big-endian integer. The message has to be
+// smaller than the modulus as a number. Leading zero bytes don't survive the
+// round trip.
+func (pub *PublicKey) EncryptBytes(message []byte) ([]byte, error) {
+	m := new(big.Int).SetBytes(message)
+	if m.Cmp(pub.N) >= 0 {
+		return nil, fmt.Errorf("message is too long for a %d-bit key", pub.N.BitLen())
+	}
+	return pub.Encrypt(m).Bytes(), nil
+}
+
+// Decrypts the ciphertext, and returns the plaintext integer's bytes.
+func (priv *PrivateKey) DecryptBytes(ciphertext []byte) []byte {
+	return priv.Decrypt(new(big.Int).SetBytes(ciphertext)).Bytes()
+}
+
+// Checks InvMod against the challenge's example, and against math/big.
+func checkInvMod() (bool, error) {
+	got, err := InvMod(big.NewInt(17), big.NewInt(3120))
+	if err != nil {
+		return false, err
+	}
+	if got.Cmp(big.NewInt(2753)) != 0 {
+		return false, nil
+	}
+
+	if _, err := InvMod(big.NewInt(6), big.NewInt(9)); err == nil {
+		return false, fmt.Errorf("6 shouldn't be invertible mod 9")
+	}
+
+	for i := 0; i < 100; i++ {
+		m, err := rand.Prime(rand.Reader, 256)
+		if err != nil {
+			return false, err
+		}
+		a, err := rand.Int(rand.Reader, m)
+		if err != nil {
+			return false, err
+		}
+		if a.Sign() == 0 {
+			continue
+		}
+		got, err := InvMod(a, m)
+		if err != nil {
+			return false, err
+		}
+		if got.Cmp(new(big.Int).ModInverse(a, m)) != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func Run() {
+	ok, err := checkInvMod()
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkInvMod passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkInvMod failed"))
+	}
+
+	// No key has an even public exponent, so asking for one should fail
+	// rather than search forever.
+	for _, e := range []int64{-3, 1, 2, 65536} {
+		if _, err := GenerateKey(1024, big.NewInt(e)); err == nil {
+			log.Fatal(fmt.Errorf("GenerateKey accepted e = %d", e))
+		}
+	}
+	fmt.Println("exponent check passed")
+
+	key, err := GenerateKey(1024, DefaultE())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m := big.NewInt(42)
+	if got := key.Decrypt(key.Encrypt(m)); got.Cmp(m) != 0 {
+		log.Fatal(fmt.Errorf("decrypt(encrypt(42)) = %s", got))
+	}
+	fmt.Println("integer round trip passed")
+
+	message := []byte("Textbook RSA is textbook for a reason")
+	ciphertext, err := key.EncryptBytes(message)
+	if err != nil {
+		log.Fatal(err)
+	}
+	got := key.DecryptBytes(ciphertext)
+	switch {
+	case string(got) == string(message):
+		fmt.Printf("got expected message: %q\n", got)
+	default:
+		fmt.Printf("got unexpected message: %q\n", got)
+	}
+}
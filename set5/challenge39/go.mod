@@ -0,0 +1,3 @@
+module cryptopals/set5/challenge39
+
+go 1.15
@@ -0,0 +1,149 @@
+package challenge40
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge39"
+)
+
+// Returns the unique x in [0, product of moduli) with x = residues[i] mod
+// moduli[i] for every i, by the Chinese Remainder Theorem. The moduli must be
+// pairwise coprime.
+//
+// For each i, the product of all the other moduli is 0 mod every other
+// modulus, and multiplying it by its own inverse mod moduli[i] makes it 1 mod
+// moduli[i]. So the sum of residues[i] times those terms has every residue
+// we want.
+// https://en.wikipedia.org/wiki/Chinese_remainder_theorem#Existence_(direct_construction)
+func CRT(residues, moduli []*big.Int) (*big.Int, error) {
+	if len(residues) != len(moduli) {
+		return nil, fmt.Errorf("got %d residues but %d moduli", len(residues), len(moduli))
+	}
+
+	product := big.NewInt(1)
+	for _, m := range moduli {
+		product.Mul(product, m)
+	}
+
+	result := new(big.Int)
+	for i, m := range moduli {
+		others := new(big.Int).Div(product, m)
+		inverse, err := challenge39.InvMod(others, m)
+		if err != nil {
+			return nil, fmt.Errorf("moduli aren't pairwise coprime: %v", err)
+		}
+		term := new(big.Int).Mul(residues[i], others)
+		term.Mul(term, inverse)
+		result.Add(result, term)
+	}
+	return result.Mod(result, product), nil
+}
+
+// Returns the largest integer r with r^n <= x, and whether r^n == x. x must
+// not be negative.
+//
+// Uses Newton's method on f(r) = r^n - x, in integers: starting above the
+// root, each step r' = ((n-1)r + x/r^(n-1)) / n moves down towards it, and
+// the first step that doesn't move down means we've reached it.
+func NthRoot(x *big.Int, n int) (*big.Int, bool) {
+	if x.Sign() < 0 || n < 1 {
+		panic("challenge40: NthRoot needs x >= 0 and n >= 1")
+	}
+	if x.Sign() == 0 {
+		return new(big.Int), true
+	}
+
+	bigN := big.NewInt(int64(n))
+	nMinusOne := big.NewInt(int64(n - 1))
+
+	// 2^ceil(bits/n) is at least the root, since x < 2^bits.
+	r := new(big.Int).Lsh(big.NewInt(1), uint((x.BitLen()+n-1)/n))
+	for {
+		next := new(big.Int).Exp(r, nMinusOne, nil)
+		next.Div(x, next)
+		next.Add(next, new(big.Int).Mul(nMinusOne, r))
+		next.Div(next, bigN)
+		if next.Cmp(r) >= 0 {
+			break
+		}
+		r = next
+	}
+
+	return r, new(big.Int).Exp(r, bigN, nil).Cmp(x) == 0
+}
+
+// Recovers a message that was encrypted under three different public keys
+// with e = 3. By CRT, the ciphertexts combine into m^3 mod n_0*n_1*n_2.
+// m is smaller than each modulus, so m^3 is smaller than their product, and
+// the result is m^3 itself -- no modular cube root needed.
+func recoverBroadcast(ciphertexts []*big.Int, keys []*challenge39.PublicKey) (*big.Int, error) {
+	var moduli []*big.Int
+	for _, k := range keys {
+		if k.E.Cmp(big.NewInt(int64(len(keys)))) != 0 {
+			return nil, fmt.Errorf("need as many ciphertexts as the public exponent, got e = %s", k.E)
+		}
+		moduli = append(moduli, k.N)
+	}
+
+	cubed, err := CRT(ciphertexts, moduli)
+	if err != nil {
+		return nil, err
+	}
+	m, exact := NthRoot(cubed, len(keys))
+	if !exact {
+		return nil, fmt.Errorf("combined ciphertext isn't a perfect power")
+	}
+	return m, nil
+}
+
+// Checks NthRoot on perfect powers and their neighbours.
+func checkNthRoot() bool {
+	for _, n := range []int{2, 3, 5} {
+		for _, r := range []int64{1, 2, 3, 1000, 123456789} {
+			root := big.NewInt(r)
+			x := new(big.Int).Exp(root, big.NewInt(int64(n)), nil)
+			if got, exact := NthRoot(x, n); got.Cmp(root) != 0 || !exact {
+				return false
+			}
+			x.Add(x, big.NewInt(1))
+			if got, exact := NthRoot(x, n); got.Cmp(root) != 0 || exact {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func Run() {
+	if !checkNthRoot() {
+		log.Fatal(fmt.Errorf("checkNthRoot failed"))
+	}
+	fmt.Println("checkNthRoot passed")
+
+	message := []byte("Broadcasting to three recipients with e = 3")
+	m := new(big.Int).SetBytes(message)
+
+	// The same message, encrypted to three different public keys.
+	var keys []*challenge39.PublicKey
+	var ciphertexts []*big.Int
+	for i := 0; i < 3; i++ {
+		key, err := challenge39.GenerateKey(1024, big.NewInt(3))
+		if err != nil {
+			log.Fatal(err)
+		}
+		keys = append(keys, &key.PublicKey)
+		ciphertexts = append(ciphertexts, key.Encrypt(m))
+	}
+
+	recovered, err := recoverBroadcast(ciphertexts, keys)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case recovered.Cmp(m) == 0:
+		fmt.Printf("got expected message: %q\n", recovered.Bytes())
+	default:
+		fmt.Printf("got unexpected message: %q\n", recovered.Bytes())
+	}
+}
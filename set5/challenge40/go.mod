@@ -0,0 +1,3 @@
+module cryptopals/set5/challenge40
+
+go 1.15
@@ -0,0 +1,39 @@
+module cryptopals/set5
+
+go 1.15
+
+replace cryptopals/set5/challenge33 => ./challenge33
+
+require (
+	cryptopals/set1/challenge7 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set2/challenge9 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set2/challenge10 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge33 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge34 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge35 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge36 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge37 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge38 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge39 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge40 v0.0.0-00010101000000-000000000000 // indirect
+)
+
+replace cryptopals/set1/challenge7 => ../set1/challenge7
+
+replace cryptopals/set2/challenge9 => ../set2/challenge9
+
+replace cryptopals/set2/challenge10 => ../set2/challenge10
+
+replace cryptopals/set5/challenge34 => ./challenge34
+
+replace cryptopals/set5/challenge35 => ./challenge35
+
+replace cryptopals/set5/challenge36 => ./challenge36
+
+replace cryptopals/set5/challenge37 => ./challenge37
+
+replace cryptopals/set5/challenge38 => ./challenge38
+
+replace cryptopals/set5/challenge39 => ./challenge39
+
+replace cryptopals/set5/challenge40 => ./challenge40
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"cryptopals/set5/challenge33"
+	"cryptopals/set5/challenge34"
+	"cryptopals/set5/challenge35"
+	"cryptopals/set5/challenge36"
+	"cryptopals/set5/challenge37"
+	"cryptopals/set5/challenge38"
+	"cryptopals/set5/challenge39"
+	"cryptopals/set5/challenge40"
+)
+
+func runChallenge(runFn func(), challengeNumber int) {
+	fmt.Printf("Challenge %d:\n", challengeNumber)
+	runFn()
+	fmt.Println()
+}
+
+func main() {
+	runChallenge(challenge33.Run, 33)
+	runChallenge(challenge34.Run, 34)
+	runChallenge(challenge35.Run, 35)
+	runChallenge(challenge36.Run, 36)
+	runChallenge(challenge37.Run, 37)
+	runChallenge(challenge38.Run, 38)
+	runChallenge(challenge39.Run, 39)
+	runChallenge(challenge40.Run, 40)
+}
@@ -0,0 +1,107 @@
+package challenge41
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+
+	"cryptopals/set5/challenge39"
+)
+
+var errAlreadyDecrypted = errors.New("ciphertext has already been decrypted")
+
+// A server that decrypts any RSA ciphertext, but only once. It remembers the
+// hash of each ciphertext it has decrypted and refuses to decrypt it again.
+type decryptionServer struct {
+	key *challenge39.PrivateKey
+
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]bool
+}
+
+func newDecryptionServer(key *challenge39.PrivateKey) *decryptionServer {
+	return &decryptionServer{key: key, seen: make(map[[sha256.Size]byte]bool)}
+}
+
+func (s *decryptionServer) decrypt(c *big.Int) (*big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := sha256.Sum256(c.Bytes())
+	if s.seen[h] {
+		return nil, errAlreadyDecrypted
+	}
+	s.seen[h] = true
+	return s.key.Decrypt(c), nil
+}
+
+// Recovers the plaintext of a ciphertext that the server has already
+// decrypted once. Unpadded RSA is multiplicative: (s^e * c)^d = s * m mod n.
+// So we blind the ciphertext with a random s, which gives a ciphertext the
+// server hasn't seen, and divide s back out of what it decrypts to.
+func recoverPlaintext(c *big.Int, pub *challenge39.PublicKey, decrypt func(c *big.Int) (*big.Int, error)) (*big.Int, error) {
+	var s *big.Int
+	for {
+		var err error
+		s, err = rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		// s = 0 or 1 wouldn't disguise anything.
+		if s.Cmp(big.NewInt(1)) > 0 {
+			break
+		}
+	}
+
+	blinded := new(big.Int).Exp(s, pub.E, pub.N)
+	blinded.Mul(blinded, c)
+	blinded.Mod(blinded, pub.N)
+
+	p, err := decrypt(blinded)
+	if err != nil {
+		return nil, err
+	}
+
+	sInverse, err := challenge39.InvMod(s, pub.N)
+	if err != nil {
+		return nil, err
+	}
+	m := p.Mul(p, sInverse)
+	return m.Mod(m, pub.N), nil
+}
+
+func Run() {
+	key, err := challenge39.GenerateKey(1024, challenge39.DefaultE())
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := newDecryptionServer(key)
+
+	message := []byte(`{time: 1356304276, social: '555-55-5555'}`)
+	m := new(big.Int).SetBytes(message)
+	c := key.Encrypt(m)
+
+	// The intended recipient decrypts the message, so the server won't
+	// decrypt it again.
+	if _, err := server.decrypt(c); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := server.decrypt(c); err != errAlreadyDecrypted {
+		log.Fatal(fmt.Errorf("second decryption: got %v, want %v", err, errAlreadyDecrypted))
+	}
+	fmt.Println("server refused to decrypt the ciphertext twice")
+
+	recovered, err := recoverPlaintext(c, &key.PublicKey, server.decrypt)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case recovered.Cmp(m) == 0:
+		fmt.Printf("got expected message: %q\n", recovered.Bytes())
+	default:
+		fmt.Printf("got unexpected message: %q\n", recovered.Bytes())
+	}
+}
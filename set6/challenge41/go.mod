@@ -0,0 +1,3 @@
+module cryptopals/set6/challenge41
+
+go 1.15
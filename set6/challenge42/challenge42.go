@@ -0,0 +1,196 @@
+package challenge42
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge39"
+	"cryptopals/set5/challenge40"
+)
+
+// PKCS#1 v1.5 signatures: the signer hashes the message, wraps the hash in an
+// ASN.1 DigestInfo saying which hash it is, and pads it out to the size of
+// the modulus as
+//   00 01 FF FF ... FF 00 DigestInfo
+// then signs that with the raw RSA private key operation.
+// https://www.rfc-editor.org/rfc/rfc8017#section-9.2
+
+// Hash is a hash function a signature can use.
+type Hash struct {
+	Name string
+	// The DER encoding of the DigestInfo, up to where the digest starts.
+	prefix []byte
+	sum    func(message []byte) []byte
+}
+
+// The DigestInfo prefixes are from RFC 8017, section 9.2, note 1.
+var (
+	SHA1 = Hash{
+		Name:   "SHA-1",
+		prefix: []byte{0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+		sum: func(message []byte) []byte {
+			digest := sha1.Sum(message)
+			return digest[:]
+		},
+	}
+	SHA256 = Hash{
+		Name:   "SHA-256",
+		prefix: []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+		sum: func(message []byte) []byte {
+			digest := sha256.Sum256(message)
+			return digest[:]
+		},
+	}
+)
+
+// Returns the DigestInfo for the message: the prefix followed by the digest.
+func (h Hash) digestInfo(message []byte) []byte {
+	return append(append([]byte(nil), h.prefix...), h.sum(message)...)
+}
+
+// Pads the DigestInfo for the message out to k bytes.
+func EncodeSignature(h Hash, message []byte, k int) ([]byte, error) {
+	digestInfo := h.digestInfo(message)
+	// 00 01, at least eight bytes of FF, and 00.
+	if k < len(digestInfo)+11 {
+		return nil, fmt.Errorf("a %d-byte key is too short for a %s signature", k, h.Name)
+	}
+
+	encoded := []byte{0x00, 0x01}
+	encoded = append(encoded, bytes.Repeat([]byte{0xff}, k-len(digestInfo)-3)...)
+	encoded = append(encoded, 0x00)
+	return append(encoded, digestInfo...), nil
+}
+
+// Signs the message: the encoded signature, raised to d.
+func Sign(priv *challenge39.PrivateKey, h Hash, message []byte) ([]byte, error) {
+	k := priv.Size()
+	encoded, err := EncodeSignature(h, message, k)
+	if err != nil {
+		return nil, err
+	}
+	s := priv.Decrypt(new(big.Int).SetBytes(encoded))
+	return challenge39.LeftPad(s.Bytes(), k), nil
+}
+
+// Undoes the signature with the public key, and returns the encoded block it
+// hides.
+func openSignature(pub *challenge39.PublicKey, signature []byte) ([]byte, bool) {
+	s := new(big.Int).SetBytes(signature)
+	if s.Cmp(pub.N) >= 0 {
+		return nil, false
+	}
+	return challenge39.LeftPad(pub.Encrypt(s).Bytes(), pub.Size()), true
+}
+
+// Verifies the signature the way a lot of implementations used to: it reads
+// the padding from the left, then checks the DigestInfo and digest that
+// follow it -- but never checks that the digest is at the very end of the
+// block. Whatever comes after it is ignored.
+func VerifySloppy(pub *challenge39.PublicKey, h Hash, message, signature []byte) bool {
+	block, ok := openSignature(pub, signature)
+	if !ok || len(block) < 2 || block[0] != 0x00 || block[1] != 0x01 {
+		return false
+	}
+
+	// Skip the FF bytes, and the 00 that ends them.
+	i := 2
+	for i < len(block) && block[i] == 0xff {
+		i++
+	}
+	if i == len(block) || block[i] != 0x00 {
+		return false
+	}
+	i++
+
+	return bytes.HasPrefix(block[i:], h.digestInfo(message))
+}
+
+// Verifies the signature by encoding what it should be and comparing the
+// whole block, so there's nowhere to hide anything.
+func VerifyStrict(pub *challenge39.PublicKey, h Hash, message, signature []byte) bool {
+	block, ok := openSignature(pub, signature)
+	if !ok {
+		return false
+	}
+	want, err := EncodeSignature(h, message, pub.Size())
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(block, want)
+}
+
+// Forges a signature for an e = 3 key that VerifySloppy accepts, without the
+// private key.
+//
+// We build a block that starts 00 01 FF 00 DigestInfo and is all FF after
+// that. Its integer cube root, cubed, comes out a little smaller -- but
+// cubes of numbers that size are close enough together that only the
+// garbage after the digest changes, as long as there's enough of it. The
+// sloppy verifier never looks at that part.
+func Forge(pub *challenge39.PublicKey, h Hash, message []byte) ([]byte, error) {
+	if pub.E.Cmp(big.NewInt(3)) != 0 {
+		return nil, fmt.Errorf("forging needs e = 3, got e = %s", pub.E)
+	}
+
+	k := pub.Size()
+	prefix := []byte{0x00, 0x01, 0xff, 0x00}
+	prefix = append(prefix, h.digestInfo(message)...)
+	if len(prefix) > k {
+		return nil, fmt.Errorf("a %d-byte key is too short for a %s signature", k, h.Name)
+	}
+	block := append(prefix, bytes.Repeat([]byte{0xff}, k-len(prefix))...)
+
+	root, _ := challenge40.NthRoot(new(big.Int).SetBytes(block), 3)
+	cubed := challenge39.LeftPad(new(big.Int).Exp(root, big.NewInt(3), nil).Bytes(), k)
+	if !bytes.HasPrefix(cubed, prefix) {
+		return nil, fmt.Errorf("not enough room after the digest to forge a %s signature with a %d-bit key", h.Name, pub.N.BitLen())
+	}
+	return challenge39.LeftPad(root.Bytes(), k), nil
+}
+
+func Run() {
+	message := []byte("hi mom")
+
+	// A SHA-256 DigestInfo is longer, so there's less garbage to absorb the
+	// cube root's error, and it needs a bigger key.
+	cases := []struct {
+		h    Hash
+		bits int
+	}{
+		{SHA1, 1024},
+		{SHA256, 2048},
+	}
+	for _, c := range cases {
+		key, err := challenge39.GenerateKey(c.bits, big.NewInt(3))
+		if err != nil {
+			log.Fatal(err)
+		}
+		pub := &key.PublicKey
+
+		signature, err := Sign(key, c.h, message)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !VerifySloppy(pub, c.h, message, signature) || !VerifyStrict(pub, c.h, message, signature) {
+			log.Fatal(fmt.Errorf("%s: a real signature didn't verify", c.h.Name))
+		}
+
+		forged, err := Forge(pub, c.h, message)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sloppy := VerifySloppy(pub, c.h, message, forged)
+		strict := VerifyStrict(pub, c.h, message, forged)
+		switch {
+		case sloppy && !strict:
+			fmt.Printf("%s: got expected result: sloppy verifier accepts the forgery, strict one rejects it\n", c.h.Name)
+		default:
+			fmt.Printf("%s: got unexpected result: sloppy verifier %t, strict verifier %t\n", c.h.Name, sloppy, strict)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package challenge43
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge39"
+)
+
+// DSA, as in FIPS 186: the signer picks a random nonce k for each signature,
+// and
+//   r = (g^k mod p) mod q
+//   s = k^-1 (H(m) + x*r) mod q
+// Anyone who learns k for a signature can solve the second equation for the
+// private key x.
+// https://en.wikipedia.org/wiki/Digital_Signature_Algorithm
+
+// The domain parameters: q divides p - 1, and g has order q mod p.
+type Params struct {
+	P, Q, G *big.Int
+}
+
+func fromHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic(fmt.Sprintf("challenge43: bad hex constant %q", s))
+	}
+	return n
+}
+
+// Returns the parameters the challenges use.
+func DefaultParams() *Params {
+	return &Params{
+		P: fromHex("800000000000000089e1855218a0e7dac38136ffafa72eda7" +
+			"859f2171e25e65eac698c1702578b07dc2a1076da241c76c6" +
+			"2d374d8389ea5aeffd3226a0530cc565f3bf6b50929139ebe" +
+			"ac04f48c3c84afb796d61e5a4f9a8fda812ab59494232c7d2" +
+			"b4deb50aa18ee9e132bfa85ac4374d7f9091abc3d015efc87" +
+			"1a584471bb1"),
+		Q: fromHex("f4f47f05794b256174bba6e9b396a7707e563c5b"),
+		G: fromHex("5958c9d3898b224b12672c0b98e06c60df923cb8bc999d119" +
+			"458fef538b8fa4046c8db53039db620c094c9fa077ef389b5" +
+			"322a559946a71903f990f1f7e0e025e2d7f7cf494aff1a047" +
+			"0f5b64c36b625a097f1651fe775323556fe00b3608c887892" +
+			"878480e99041be601a62166ca6894bdd41a7054ec89f756ba" +
+			"9fc95302291"),
+	}
+}
+
+// A DSA public key: the parameters, and y = g^x mod p.
+type PublicKey struct {
+	Params
+	Y *big.Int
+}
+
+// A DSA private key: the public key, and x.
+type PrivateKey struct {
+	PublicKey
+	X *big.Int
+}
+
+// A DSA signature, (r, s).
+type Signature struct {
+	R, S *big.Int
+}
+
+// Generates a key pair: a random x in [1, q), and y = g^x mod p.
+func GenerateKey(params *Params) (*PrivateKey, error) {
+	x, err := rand.Int(rand.Reader, new(big.Int).Sub(params.Q, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	x.Add(x, big.NewInt(1))
+	return &PrivateKey{
+		PublicKey: PublicKey{Params: *params, Y: new(big.Int).Exp(params.G, x, params.P)},
+		X:         x,
+	}, nil
+}
+
+// Returns the SHA-1 hash of the message, as an integer.
+func Hash(message []byte) *big.Int {
+	digest := sha1.Sum(message)
+	return new(big.Int).SetBytes(digest[:])
+}
+
+// Signs the hash h using the nonce k. It doesn't check whether r or s came
+// out 0, which a careful signer would.
+func SignWithNonce(priv *PrivateKey, h, k *big.Int) (*Signature, error) {
+	p, q := priv.P, priv.Q
+	kInverse, err := challenge39.InvMod(k, q)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(big.Int).Exp(priv.G, k, p)
+	r.Mod(r, q)
+
+	s := new(big.Int).Mul(priv.X, r)
+	s.Add(s, h)
+	s.Mul(s, kInverse)
+	s.Mod(s, q)
+	return &Signature{R: r, S: s}, nil
+}
+
+// Signs the hash h with a random nonce, trying again with another one if r or
+// s comes out 0.
+func Sign(priv *PrivateKey, h *big.Int) (*Signature, error) {
+	for {
+		k, err := rand.Int(rand.Reader, priv.Q)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() == 0 {
+			continue
+		}
+		sig, err := SignWithNonce(priv, h, k)
+		if err != nil {
+			return nil, err
+		}
+		if sig.R.Sign() != 0 && sig.S.Sign() != 0 {
+			return sig, nil
+		}
+	}
+}
+
+// Checks that g^(h/s) y^(r/s) mod p mod q = r. That's g^k mod p mod q for an
+// honest signature, since k = (h + x*r)/s mod q.
+func verify(pub *PublicKey, h *big.Int, sig *Signature) bool {
+	p, q := pub.P, pub.Q
+	w, err := challenge39.InvMod(sig.S, q)
+	if err != nil {
+		return false
+	}
+	u1 := new(big.Int).Mul(h, w)
+	u1.Mod(u1, q)
+	u2 := new(big.Int).Mul(sig.R, w)
+	u2.Mod(u2, q)
+
+	v := new(big.Int).Exp(pub.G, u1, p)
+	v.Mul(v, new(big.Int).Exp(pub.Y, u2, p))
+	v.Mod(v, p)
+	v.Mod(v, q)
+	return v.Cmp(sig.R) == 0
+}
+
+// Verifies the signature for the hash h. r and s have to be in (0, q).
+func Verify(pub *PublicKey, h *big.Int, sig *Signature) bool {
+	for _, n := range []*big.Int{sig.R, sig.S} {
+		if n.Sign() <= 0 || n.Cmp(pub.Q) >= 0 {
+			return false
+		}
+	}
+	return verify(pub, h, sig)
+}
+
+// Verifies the signature for the hash h without checking that r and s are in
+// range, so r = 0 gets through.
+func VerifySloppy(pub *PublicKey, h *big.Int, sig *Signature) bool {
+	return verify(pub, h, sig)
+}
+
+// Returns the private key that made the signature of h, given the nonce k it
+// used: x = (s*k - h) / r mod q.
+func RecoverPrivateKey(params *Params, h *big.Int, sig *Signature, k *big.Int) (*big.Int, error) {
+	q := params.Q
+	rInverse, err := challenge39.InvMod(sig.R, q)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).Mul(sig.S, k)
+	x.Sub(x, h)
+	x.Mul(x, rInverse)
+	return x.Mod(x, q), nil
+}
+
+// Returns the SHA-1 hash of the private key's hex encoding, in hex. That's
+// how the challenges give the keys we're meant to find.
+func Fingerprint(x *big.Int) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(x.Text(16))))
+}
+
+// Recovers the private key for pub from a signature whose nonce was at most
+// maxNonce, by trying each nonce. The right one is the one with
+// g^k mod p mod q = r, and we check the key it gives against y.
+func recoverFromWeakNonce(pub *PublicKey, h *big.Int, sig *Signature, maxNonce int64) (*big.Int, bool) {
+	r := new(big.Int)
+	for k := int64(1); k <= maxNonce; k++ {
+		bigK := big.NewInt(k)
+		r.Exp(pub.G, bigK, pub.P)
+		if r.Mod(r, pub.Q).Cmp(sig.R) != 0 {
+			continue
+		}
+
+		x, err := RecoverPrivateKey(&pub.Params, h, sig, bigK)
+		if err != nil {
+			continue
+		}
+		if new(big.Int).Exp(pub.G, x, pub.P).Cmp(pub.Y) == 0 {
+			return x, true
+		}
+	}
+	return nil, false
+}
+
+// Checks that signatures verify, and stop verifying when the message or the
+// signature changes.
+func checkSignVerify() (bool, error) {
+	key, err := GenerateKey(DefaultParams())
+	if err != nil {
+		return false, err
+	}
+	h := Hash([]byte("sign me"))
+	sig, err := Sign(key, h)
+	if err != nil {
+		return false, err
+	}
+
+	if !Verify(&key.PublicKey, h, sig) {
+		return false, nil
+	}
+	if Verify(&key.PublicKey, Hash([]byte("sign me too")), sig) {
+		return false, nil
+	}
+	tampered := &Signature{R: sig.R, S: new(big.Int).Add(sig.S, big.NewInt(1))}
+	return !Verify(&key.PublicKey, h, tampered), nil
+}
+
+func Run() {
+	ok, err := checkSignVerify()
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkSignVerify passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkSignVerify failed"))
+	}
+
+	pub := &PublicKey{
+		Params: *DefaultParams(),
+		Y: fromHex("84ad4719d044495496a3201c8ff484feb45b962e7302e56a392aee4" +
+			"abab3e4bdebf2955b4736012f21a08084056b19bcd7fee56048e004" +
+			"e44984e2f411788efdc837a0d2e5abb7b555039fd243ac01f0fb2ed" +
+			"1dec568280ce678e931868d23eb095fde9d3779191b8c0299d6e07b" +
+			"bb283e6633451e535c45513b2d33c99ea17"),
+	}
+	message := []byte("For those that envy a MC it can be hazardous to your health\n" +
+		"So be friendly, a matter of life and death, just like a etch-a-sketch\n")
+	sig := &Signature{}
+	sig.R, _ = new(big.Int).SetString("548099063082341131477253921760299949438196259240", 10)
+	sig.S, _ = new(big.Int).SetString("857042759984254168557880549501802188789837994940", 10)
+
+	h := Hash(message)
+	if !Verify(pub, h, sig) {
+		log.Fatal(fmt.Errorf("the challenge's signature doesn't verify"))
+	}
+
+	// The nonce was somewhere between 0 and 2^16.
+	x, found := recoverFromWeakNonce(pub, h, sig, 1<<16)
+	want := "0954edd5e0afe5542a4adf012611a91912a3ec16"
+	switch {
+	case !found:
+		fmt.Println("got unexpected result: no nonce up to 2^16 works")
+	case Fingerprint(x) == want:
+		fmt.Printf("got expected key fingerprint: %s\n", Fingerprint(x))
+	default:
+		fmt.Printf("got unexpected key fingerprint: %s\n", Fingerprint(x))
+	}
+}
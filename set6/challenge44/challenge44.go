@@ -0,0 +1,185 @@
+package challenge44
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"cryptopals/set5/challenge39"
+	"cryptopals/set6/challenge43"
+)
+
+// A message, its SHA-1 hash, and its signature, as they appear in the data
+// file.
+type signedMessage struct {
+	message string
+	hash    *big.Int
+	sig     *challenge43.Signature
+}
+
+// Reads signed messages from the file. Each one is four lines: "msg: " and
+// the message, "s: " and "r: " and the signature in decimal, and "m: " and
+// the message's SHA-1 hash in hex.
+func readSignedMessages(path string) ([]signedMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines)%4 != 0 {
+		return nil, fmt.Errorf("%s: got %d lines, want a multiple of 4", path, len(lines))
+	}
+
+	// Returns the value of the line, which has to start with the label.
+	field := func(line, label string) (string, error) {
+		if !strings.HasPrefix(line, label+": ") {
+			return "", fmt.Errorf("%s: expected %q line, got %q", path, label, line)
+		}
+		return strings.TrimPrefix(line, label+": "), nil
+	}
+	number := func(line, label string, base int) (*big.Int, error) {
+		value, err := field(line, label)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := new(big.Int).SetString(strings.TrimSpace(value), base)
+		if !ok {
+			return nil, fmt.Errorf("%s: bad %q value %q", path, label, value)
+		}
+		return n, nil
+	}
+
+	var signed []signedMessage
+	for i := 0; i < len(lines); i += 4 {
+		var m signedMessage
+		var s, r *big.Int
+		if m.message, err = field(lines[i], "msg"); err != nil {
+			return nil, err
+		}
+		if s, err = number(lines[i+1], "s", 10); err != nil {
+			return nil, err
+		}
+		if r, err = number(lines[i+2], "r", 10); err != nil {
+			return nil, err
+		}
+		if m.hash, err = number(lines[i+3], "m", 16); err != nil {
+			return nil, err
+		}
+		m.sig = &challenge43.Signature{R: r, S: s}
+		signed = append(signed, m)
+	}
+	return signed, nil
+}
+
+// Returns the pairs of messages that were signed with the same nonce. r only
+// depends on the nonce, so those are the pairs with the same r.
+func findRepeatedNonces(signed []signedMessage) [][2]signedMessage {
+	byR := make(map[string][]signedMessage)
+	var order []string
+	for _, m := range signed {
+		r := m.sig.R.String()
+		if _, ok := byR[r]; !ok {
+			order = append(order, r)
+		}
+		byR[r] = append(byR[r], m)
+	}
+
+	var pairs [][2]signedMessage
+	for _, r := range order {
+		group := byR[r]
+		for i := 1; i < len(group); i++ {
+			pairs = append(pairs, [2]signedMessage{group[0], group[i]})
+		}
+	}
+	return pairs
+}
+
+// Returns the nonce two messages were both signed with. With the same k and
+// so the same r, subtracting one s from the other cancels the x*r terms:
+// s1 - s2 = k^-1 (h1 - h2) mod q, so k = (h1 - h2) / (s1 - s2) mod q.
+func recoverNonce(params *challenge43.Params, a, b signedMessage) (*big.Int, error) {
+	q := params.Q
+	ds := new(big.Int).Sub(a.sig.S, b.sig.S)
+	ds.Mod(ds, q)
+	dsInverse, err := challenge39.InvMod(ds, q)
+	if err != nil {
+		return nil, err
+	}
+	k := new(big.Int).Sub(a.hash, b.hash)
+	k.Mul(k, dsInverse)
+	return k.Mod(k, q), nil
+}
+
+// Finds a pair of signatures with a repeated nonce, and recovers the private
+// key from it. The key is checked against y.
+func recoverPrivateKey(pub *challenge43.PublicKey, signed []signedMessage) (*big.Int, error) {
+	pairs := findRepeatedNonces(signed)
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no two messages share a nonce")
+	}
+
+	for _, pair := range pairs {
+		k, err := recoverNonce(&pub.Params, pair[0], pair[1])
+		if err != nil {
+			continue
+		}
+		x, err := challenge43.RecoverPrivateKey(&pub.Params, pair[0].hash, pair[0].sig, k)
+		if err != nil {
+			continue
+		}
+		if new(big.Int).Exp(pub.G, x, pub.P).Cmp(pub.Y) == 0 {
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the %d repeated nonces gives the private key", len(pairs))
+}
+
+func Run() {
+	signed, err := readSignedMessages("/home/swalters4925/cryptopals/set6/challenge44/data.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, m := range signed {
+		if m.hash.Cmp(challenge43.Hash([]byte(m.message))) != 0 {
+			log.Fatal(fmt.Errorf("m doesn't match the SHA-1 of %q", m.message))
+		}
+	}
+
+	pairs := findRepeatedNonces(signed)
+	fmt.Printf("found %d pairs of messages signed with the same nonce:\n", len(pairs))
+	for _, pair := range pairs {
+		fmt.Printf("  %q\n  %q\n", pair[0].message, pair[1].message)
+	}
+
+	// The public key that signed 44.txt, from the challenge.
+	y, _ := new(big.Int).SetString("2d026f4bf30195ede3a088da85e398ef869611d0f68f0713d51c9c1a3a26c951"+
+		"05d915e2d8cdf26d056b86b8a7b85519b1c23cc3ecdc6062650462e3063bd179"+
+		"c2a6581519f674a61f1d89a1fff27171ebc1b93d4dc57bceb7ae2430f98a6a4d"+
+		"83d8279ee65d71c1203d2c96d65ebbf7cce9d32971c3de5084cce04a2e147821", 16)
+	pub := &challenge43.PublicKey{Params: *challenge43.DefaultParams(), Y: y}
+
+	x, err := recoverPrivateKey(pub, signed)
+	want := "ca8f6f7c66fa362d40760d135b763eb8527d3d52"
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case challenge43.Fingerprint(x) == want:
+		fmt.Printf("got expected key fingerprint: %s\n", challenge43.Fingerprint(x))
+	default:
+		fmt.Printf("got unexpected key fingerprint: %s\n", challenge43.Fingerprint(x))
+	}
+}
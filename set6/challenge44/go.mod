@@ -0,0 +1,3 @@
+module cryptopals/set6/challenge44
+
+go 1.15
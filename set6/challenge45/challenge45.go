@@ -0,0 +1,105 @@
+package challenge45
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge39"
+	"cryptopals/set6/challenge43"
+)
+
+// If an attacker can choose g, the signatures stop meaning anything.
+
+// Signs "Hello, world" with g = 0. Then y = 0 and r = 0, and since the
+// verifier computes v = g^u1 y^u2 = 0 too, the signature verifies for any
+// message -- as long as the verifier doesn't insist that r > 0.
+func zeroGenerator(messages []string) error {
+	params := challenge43.DefaultParams()
+	params.G = big.NewInt(0)
+	key, err := challenge43.GenerateKey(params)
+	if err != nil {
+		return err
+	}
+
+	// Sign would never finish with r always 0, so pick a nonce ourselves.
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(params.Q, big.NewInt(1)))
+	if err != nil {
+		return err
+	}
+	k.Add(k, big.NewInt(1))
+	sig, err := challenge43.SignWithNonce(key, challenge43.Hash([]byte(messages[0])), k)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		h := challenge43.Hash([]byte(m))
+		sloppy := challenge43.VerifySloppy(&key.PublicKey, h, sig)
+		strict := challenge43.Verify(&key.PublicKey, h, sig)
+		if sloppy && !strict {
+			fmt.Printf("g = 0, %q: got expected result: sloppy verifier accepts, strict one rejects\n", m)
+		} else {
+			fmt.Printf("g = 0, %q: got unexpected result: sloppy verifier %t, strict verifier %t\n", m, sloppy, strict)
+		}
+	}
+	return nil
+}
+
+// Returns a signature that verifies for any message under g = p + 1, whatever
+// y is. Every power of g is 1 mod p, so the verifier computes v = y^u2 =
+// y^(r/s) mod p mod q. Picking any z and setting r = y^z mod p mod q and
+// s = r/z mod q makes r/s = z, so v = r.
+func magicSignature(pub *challenge43.PublicKey) (*challenge43.Signature, error) {
+	q := pub.Q
+	z, err := rand.Int(rand.Reader, new(big.Int).Sub(q, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	z.Add(z, big.NewInt(1))
+
+	r := new(big.Int).Exp(pub.Y, z, pub.P)
+	r.Mod(r, q)
+	zInverse, err := challenge39.InvMod(z, q)
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).Mul(r, zInverse)
+	s.Mod(s, q)
+	return &challenge43.Signature{R: r, S: s}, nil
+}
+
+// Makes a magic signature for someone's public key, after swapping in
+// g = p + 1, and checks it against each message.
+func pPlusOneGenerator(messages []string) error {
+	key, err := challenge43.GenerateKey(challenge43.DefaultParams())
+	if err != nil {
+		return err
+	}
+	pub := key.PublicKey
+	pub.G = new(big.Int).Add(pub.P, big.NewInt(1))
+
+	sig, err := magicSignature(&pub)
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if challenge43.Verify(&pub, challenge43.Hash([]byte(m)), sig) {
+			fmt.Printf("g = p + 1, %q: got expected result: magic signature verifies\n", m)
+		} else {
+			fmt.Printf("g = p + 1, %q: got unexpected result: magic signature doesn't verify\n", m)
+		}
+	}
+	return nil
+}
+
+func Run() {
+	messages := []string{"Hello, world", "Goodbye, world"}
+	if err := zeroGenerator(messages); err != nil {
+		log.Fatal(err)
+	}
+	if err := pPlusOneGenerator(messages); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,93 @@
+package challenge46
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"math/big"
+
+	"cryptopals/set5/challenge39"
+)
+
+// Returns an oracle which decrypts a ciphertext with the key, and reports only
+// whether the plaintext is even.
+func newParityOracle(key *challenge39.PrivateKey) func(c *big.Int) bool {
+	return func(c *big.Int) bool {
+		return key.Decrypt(c).Bit(0) == 0
+	}
+}
+
+// Returns the ceiling of a non-negative rational.
+func ceil(r *big.Rat) *big.Int {
+	n := new(big.Int).Add(r.Num(), r.Denom())
+	n.Sub(n, big.NewInt(1))
+	return n.Div(n, r.Denom())
+}
+
+// Decrypts the ciphertext using only the parity oracle.
+//
+// Multiplying the ciphertext by 2^e doubles the plaintext. n is odd, so 2m
+// mod n is even if 2m didn't wrap around n, i.e. if m < n/2, and odd if it
+// did. Doubling again tells us which half of that half m is in, and so on:
+// each query halves the interval m is in. The bounds are kept as exact
+// rationals, so that after log2(n) queries the interval is narrower than 1
+// and holds just m.
+//
+// If progress isn't nil, it's called after each query with the upper bound
+// so far and how many queries have been made.
+func decrypt(pub *challenge39.PublicKey, c *big.Int, isEven func(c *big.Int) bool, progress func(upper []byte, i int)) *big.Int {
+	double := new(big.Int).Exp(big.NewInt(2), pub.E, pub.N)
+	lower := new(big.Rat)
+	upper := new(big.Rat).SetInt(pub.N)
+	c = new(big.Int).Set(c)
+
+	for i := 0; i < pub.N.BitLen(); i++ {
+		c.Mul(c, double)
+		c.Mod(c, pub.N)
+
+		mid := new(big.Rat).Add(lower, upper)
+		mid.Mul(mid, big.NewRat(1, 2))
+		if isEven(c) {
+			upper = mid
+		} else {
+			lower = mid
+		}
+
+		if progress != nil {
+			bound := new(big.Int).Div(upper.Num(), upper.Denom())
+			progress(bound.Bytes(), i)
+		}
+	}
+	return ceil(lower)
+}
+
+// Prints the upper bound every 64 queries, so the message can be watched
+// coming into focus.
+func printProgress(upper []byte, i int) {
+	if i%64 == 63 {
+		fmt.Printf("query %4d: %q\n", i+1, upper)
+	}
+}
+
+func Run() {
+	key, err := challenge39.GenerateKey(1024, challenge39.DefaultE())
+	if err != nil {
+		log.Fatal(err)
+	}
+	isEven := newParityOracle(key)
+
+	message, err := base64.StdEncoding.DecodeString("VGhhdCdzIHdoeSBJIGZvdW5kIHlvdSBkb24ndCBwbGF5IGFyb3VuZCB3aXRoIHRoZSBGdW5reSBDb2xkIE1lZGluYQ==")
+	if err != nil {
+		log.Fatal(err)
+	}
+	m := new(big.Int).SetBytes(message)
+	c := key.Encrypt(m)
+
+	recovered := decrypt(&key.PublicKey, c, isEven, printProgress)
+	switch {
+	case recovered.Cmp(m) == 0:
+		fmt.Printf("got expected message: %q\n", recovered.Bytes())
+	default:
+		fmt.Printf("got unexpected message: %q\n", recovered.Bytes())
+	}
+}
@@ -0,0 +1,262 @@
+package challenge47
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"cryptopals/set5/challenge39"
+)
+
+// Bleichenbacher's attack on PKCS#1 v1.5 encryption padding, from "Chosen
+// Ciphertext Attacks Against Protocols Based on the RSA Encryption Standard
+// PKCS #1" (1998). A server which tells us whether a ciphertext decrypts to
+// something starting 00 02 lets us narrow down the plaintext of any
+// ciphertext, one multiplier at a time.
+// http://archiv.infsec.ethz.ch/education/fs08/secsem/bleichenbacher98.pdf
+
+// Pads the message out to k bytes for encryption: 00 02, at least eight
+// random non-zero bytes, 00, and the message.
+func PadPKCS1v15(message []byte, k int) ([]byte, error) {
+	if len(message) > k-11 {
+		return nil, fmt.Errorf("a %d-byte message is too long for a %d-byte key", len(message), k)
+	}
+
+	padding := make([]byte, k-len(message)-3)
+	if _, err := rand.Read(padding); err != nil {
+		return nil, err
+	}
+	for i := range padding {
+		for padding[i] == 0 {
+			if _, err := rand.Read(padding[i : i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	padded := append([]byte{0x00, 0x02}, padding...)
+	padded = append(padded, 0x00)
+	return append(padded, message...), nil
+}
+
+var errBadPadding = errors.New("invalid PKCS#1 v1.5 padding")
+
+// Removes the padding from a k-byte block.
+func UnpadPKCS1v15(padded []byte) ([]byte, error) {
+	if len(padded) < 11 || padded[0] != 0x00 || padded[1] != 0x02 {
+		return nil, errBadPadding
+	}
+	end := bytes.IndexByte(padded[2:], 0x00)
+	if end < 8 {
+		return nil, errBadPadding
+	}
+	return padded[2+end+1:], nil
+}
+
+// Decrypts ciphertexts and reports whether their plaintext starts 00 02,
+// counting how many times it's asked.
+type PaddingOracle struct {
+	key     *challenge39.PrivateKey
+	Queries int
+}
+
+// Returns a padding oracle for the key, which hasn't been queried yet.
+func NewPaddingOracle(key *challenge39.PrivateKey) *PaddingOracle {
+	return &PaddingOracle{key: key}
+}
+
+// Reports whether c decrypts to a block starting 00 02. That's all the
+// oracle checks, so some blocks it accepts wouldn't unpad.
+func (o *PaddingOracle) Conforming(c *big.Int) bool {
+	o.Queries++
+	block := challenge39.LeftPad(o.key.Decrypt(c).Bytes(), o.key.Size())
+	return block[0] == 0x00 && block[1] == 0x02
+}
+
+// Returns ceil(x / y) for positive y.
+func ceilDiv(x, y *big.Int) *big.Int {
+	q, m := new(big.Int).DivMod(x, y, new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// A closed range [a, b] the plaintext might be in.
+type interval struct {
+	a, b *big.Int
+}
+
+// Sorts the intervals and merges the ones which overlap.
+func union(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].a.Cmp(intervals[j].a) < 0 })
+	var merged []interval
+	for _, in := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && in.a.Cmp(merged[last].b) <= 0 {
+			if in.b.Cmp(merged[last].b) > 0 {
+				merged[last].b = in.b
+			}
+			continue
+		}
+		merged = append(merged, in)
+	}
+	return merged
+}
+
+// Recovers the plaintext of c, which has to be PKCS#1 v1.5 conforming, using
+// the padding oracle. The steps are numbered as in the paper.
+//
+// A conforming plaintext m is in [2B, 3B), where B = 2^(8(k-2)). If c*s^e is
+// conforming too, then 2B <= m*s - r*n < 3B for some r, which narrows down
+// where m can be. Each conforming s we find makes the set of possible m
+// smaller, until it's a single number.
+func Attack(pub *challenge39.PublicKey, c *big.Int, conforming func(c *big.Int) bool) (*big.Int, error) {
+	n := pub.N
+	one := big.NewInt(1)
+	B := new(big.Int).Lsh(one, uint(8*(pub.Size()-2)))
+	B2 := new(big.Int).Mul(B, big.NewInt(2))
+	B3 := new(big.Int).Mul(B, big.NewInt(3))
+	B3Minus1 := new(big.Int).Sub(B3, one)
+
+	if !conforming(c) {
+		return nil, fmt.Errorf("the ciphertext isn't PKCS#1 v1.5 conforming")
+	}
+
+	// Reports whether c*s^e mod n is conforming.
+	tryS := func(s *big.Int) bool {
+		blinded := new(big.Int).Exp(s, pub.E, n)
+		blinded.Mul(blinded, c)
+		return conforming(blinded.Mod(blinded, n))
+	}
+
+	// Step 1: c is already conforming, so s0 = 1.
+	M := []interval{{a: new(big.Int).Set(B2), b: new(big.Int).Set(B3Minus1)}}
+	var s *big.Int
+
+	for i := 1; ; i++ {
+		switch {
+		case i == 1:
+			// Step 2.a: the smallest s >= n/3B that's conforming. Anything
+			// smaller can't take m from [2B, 3B) past n.
+			s = ceilDiv(n, B3)
+			for !tryS(s) {
+				s.Add(s, one)
+			}
+
+		case len(M) > 1:
+			// Step 2.b: the intervals are too spread out to aim at, so try
+			// the next s up.
+			s = new(big.Int).Add(s, one)
+			for !tryS(s) {
+				s.Add(s, one)
+			}
+
+		default:
+			// Step 2.c: one interval [a, b] left. Choosing r and s with
+			//   r >= 2(b*s - 2B)/n
+			//   (2B + r*n)/b <= s < (3B + r*n)/a
+			// about halves the interval each time.
+			a, b := M[0].a, M[0].b
+			r := new(big.Int).Mul(b, s)
+			r.Sub(r, B2)
+			r.Mul(r, big.NewInt(2))
+			r = ceilDiv(r, n)
+
+			found := false
+			for !found {
+				rn := new(big.Int).Mul(r, n)
+				low := ceilDiv(new(big.Int).Add(B2, rn), b)
+				high := ceilDiv(new(big.Int).Add(B3, rn), a)
+				for candidate := low; candidate.Cmp(high) < 0; candidate.Add(candidate, one) {
+					if tryS(candidate) {
+						s = candidate
+						found = true
+						break
+					}
+				}
+				r.Add(r, one)
+			}
+		}
+
+		// Step 3: for every interval and every r that fits, m*s - r*n is in
+		// [2B, 3B), so m is in [(2B + r*n)/s, (3B - 1 + r*n)/s].
+		var next []interval
+		for _, in := range M {
+			rLow := new(big.Int).Mul(in.a, s)
+			rLow.Sub(rLow, B3Minus1)
+			rLow = ceilDiv(rLow, n)
+			rHigh := new(big.Int).Mul(in.b, s)
+			rHigh.Sub(rHigh, B2)
+			rHigh.Div(rHigh, n)
+
+			for r := rLow; r.Cmp(rHigh) <= 0; r = new(big.Int).Add(r, one) {
+				rn := new(big.Int).Mul(r, n)
+				a := ceilDiv(new(big.Int).Add(B2, rn), s)
+				if a.Cmp(in.a) < 0 {
+					a = in.a
+				}
+				b := new(big.Int).Add(B3Minus1, rn)
+				b.Div(b, s)
+				if b.Cmp(in.b) > 0 {
+					b = in.b
+				}
+				if a.Cmp(b) <= 0 {
+					next = append(next, interval{a: a, b: b})
+				}
+			}
+		}
+		if len(next) == 0 {
+			return nil, fmt.Errorf("no intervals left after %d steps", i)
+		}
+		M = union(next)
+
+		// Step 4: one possibility left.
+		if len(M) == 1 && M[0].a.Cmp(M[0].b) == 0 {
+			return M[0].a, nil
+		}
+	}
+}
+
+// Encrypts the message under a new key of the given size, then decrypts it
+// with the padding oracle attack, and reports how many queries and how long
+// it took.
+func Demonstrate(bits int, message string) {
+	key, err := challenge39.GenerateKey(bits, challenge39.DefaultE())
+	if err != nil {
+		log.Fatal(err)
+	}
+	padded, err := PadPKCS1v15([]byte(message), key.Size())
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := key.Encrypt(new(big.Int).SetBytes(padded))
+
+	oracle := NewPaddingOracle(key)
+	start := time.Now()
+	m, err := Attack(&key.PublicKey, c, oracle.Conforming)
+	if err != nil {
+		log.Fatal(err)
+	}
+	elapsed := time.Since(start)
+
+	recovered, err := UnpadPKCS1v15(challenge39.LeftPad(m.Bytes(), key.Size()))
+	switch {
+	case err != nil:
+		fmt.Printf("got unexpected result: %v\n", err)
+	case string(recovered) == message:
+		fmt.Printf("got expected message: %q\n", recovered)
+	default:
+		fmt.Printf("got unexpected message: %q\n", recovered)
+	}
+	fmt.Printf("%d-bit key: %d oracle queries in %s\n", bits, oracle.Queries, elapsed.Round(time.Millisecond))
+}
+
+func Run() {
+	Demonstrate(256, "kick it, CC")
+}
@@ -0,0 +1,12 @@
+package challenge48
+
+import (
+	"cryptopals/set6/challenge47"
+)
+
+// The same attack as challenge 47, with a 768-bit key. Challenge 47's Attack
+// already handles more than one interval being left after a step (step 2.b),
+// which only happens now and then at either key size.
+func Run() {
+	challenge47.Demonstrate(768, "kick it, CC")
+}
@@ -0,0 +1,36 @@
+module cryptopals/set6
+
+go 1.15
+
+replace cryptopals/set6/challenge41 => ./challenge41
+
+require (
+	cryptopals/set5/challenge39 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set5/challenge40 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge41 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge42 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge43 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge44 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge45 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge46 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge47 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set6/challenge48 v0.0.0-00010101000000-000000000000 // indirect
+)
+
+replace cryptopals/set5/challenge39 => ../set5/challenge39
+
+replace cryptopals/set6/challenge42 => ./challenge42
+
+replace cryptopals/set5/challenge40 => ../set5/challenge40
+
+replace cryptopals/set6/challenge43 => ./challenge43
+
+replace cryptopals/set6/challenge44 => ./challenge44
+
+replace cryptopals/set6/challenge45 => ./challenge45
+
+replace cryptopals/set6/challenge46 => ./challenge46
+
+replace cryptopals/set6/challenge47 => ./challenge47
+
+replace cryptopals/set6/challenge48 => ./challenge48
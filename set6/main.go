@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"cryptopals/set6/challenge41"
+	"cryptopals/set6/challenge42"
+	"cryptopals/set6/challenge43"
+	"cryptopals/set6/challenge44"
+	"cryptopals/set6/challenge45"
+	"cryptopals/set6/challenge46"
+	"cryptopals/set6/challenge47"
+	"cryptopals/set6/challenge48"
+)
+
+func runChallenge(runFn func(), challengeNumber int) {
+	fmt.Printf("Challenge %d:\n", challengeNumber)
+	runFn()
+	fmt.Println()
+}
+
+func main() {
+	runChallenge(challenge41.Run, 41)
+	runChallenge(challenge42.Run, 42)
+	runChallenge(challenge43.Run, 43)
+	runChallenge(challenge44.Run, 44)
+	runChallenge(challenge45.Run, 45)
+	runChallenge(challenge46.Run, 46)
+	runChallenge(challenge47.Run, 47)
+	runChallenge(challenge48.Run, 48)
+}
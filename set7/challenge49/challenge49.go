@@ -0,0 +1,286 @@
+package challenge49
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"cryptopals/set2/challenge10"
+	"cryptopals/set2/challenge9"
+)
+
+// Pads the message with PKCS#7 to a whole number of AES blocks. A message
+// that's already a whole number of blocks gets a whole block of padding.
+func Pad(message []byte) []byte {
+	s := string(message)
+	padded, _ := challenge9.PadPKCS7(s, len(s)+aes.BlockSize-len(s)%aes.BlockSize)
+	return []byte(padded)
+}
+
+// CBC-MAC: pads the message, encrypts it with AES-CBC, and keeps only the last
+// block of ciphertext. Every block feeds into the next, so the last one
+// depends on the whole message.
+func CBCMAC(message, key, iv []byte) ([]byte, error) {
+	ciphertext, err := challenge10.EncryptAESWithCBC(Pad(message), key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return ciphertext[len(ciphertext)-aes.BlockSize:], nil
+}
+
+// XORs equal-length slices together.
+func xor(xs, ys []byte) []byte {
+	result := make([]byte, len(xs))
+	for i := range xs {
+		result[i] = xs[i] ^ ys[i]
+	}
+	return result
+}
+
+// Money moving from one account to another.
+type transfer struct {
+	from, to, amount int
+}
+
+// A toy bank API. The web front end and the API server share a key, and the
+// API server carries out any transfer request whose MAC checks out.
+type server struct {
+	key    []byte
+	ledger []transfer
+}
+
+// Reports whether the ledger has the transfer in it.
+func (s *server) hasTransfer(t transfer) bool {
+	for _, l := range s.ledger {
+		if l == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Handles a single transfer: the request is
+// "from=#{from}&to=#{to}&amount=#{amount}", then the IV, then the MAC.
+func (s *server) handleTransfer(request []byte) error {
+	if len(request) < 2*aes.BlockSize {
+		return fmt.Errorf("request is too short")
+	}
+	message := request[:len(request)-2*aes.BlockSize]
+	iv := request[len(message) : len(message)+aes.BlockSize]
+	mac := request[len(message)+aes.BlockSize:]
+
+	want, err := CBCMAC(message, s.key, iv)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, want) {
+		return fmt.Errorf("bad MAC")
+	}
+
+	var t transfer
+	for _, pair := range strings.Split(string(message), "&") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed parameter %q", pair)
+		}
+		n, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return fmt.Errorf("malformed %s: %v", kv[0], err)
+		}
+		switch kv[0] {
+		case "from":
+			t.from = n
+		case "to":
+			t.to = n
+		case "amount":
+			t.amount = n
+		}
+	}
+	s.ledger = append(s.ledger, t)
+	return nil
+}
+
+// Handles a batch of transfers from one account, with a fixed zero IV. The
+// request is "from=#{from}&tx_list=#{transactions}", then the MAC, where the
+// transactions look like "to:amount(;to:amount)*". Transactions that don't
+// parse are skipped, so one bad entry doesn't hold up the rest.
+func (s *server) handleMultiTransfer(request []byte) error {
+	if len(request) < aes.BlockSize {
+		return fmt.Errorf("request is too short")
+	}
+	message := request[:len(request)-aes.BlockSize]
+	mac := request[len(message):]
+
+	want, err := CBCMAC(message, s.key, make([]byte, aes.BlockSize))
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, want) {
+		return fmt.Errorf("bad MAC")
+	}
+
+	params := strings.SplitN(string(message), "&", 2)
+	if len(params) != 2 || !strings.HasPrefix(params[0], "from=") || !strings.HasPrefix(params[1], "tx_list=") {
+		return fmt.Errorf("malformed request %q", message)
+	}
+	from, err := strconv.Atoi(strings.TrimPrefix(params[0], "from="))
+	if err != nil {
+		return fmt.Errorf("malformed from: %v", err)
+	}
+
+	for _, tx := range strings.Split(strings.TrimPrefix(params[1], "tx_list="), ";") {
+		parts := strings.SplitN(tx, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		to, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		amount, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		s.ledger = append(s.ledger, transfer{from: from, to: to, amount: amount})
+	}
+	return nil
+}
+
+// The web front end, for a logged-in user. It only signs requests that
+// move money out of that user's own account.
+type client struct {
+	key     []byte
+	account int
+}
+
+// Returns a signed request to move amount to another account, with a random
+// IV.
+func (c *client) transfer(to, amount int) ([]byte, error) {
+	message := []byte(fmt.Sprintf("from=%d&to=%d&amount=%d", c.account, to, amount))
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	mac, err := CBCMAC(message, c.key, iv)
+	if err != nil {
+		return nil, err
+	}
+	request := append(message, iv...)
+	return append(request, mac...), nil
+}
+
+// Returns a signed request for a batch of transfers, each a pair of account
+// and amount.
+func (c *client) multiTransfer(txs [][2]int) ([]byte, error) {
+	var list []string
+	for _, tx := range txs {
+		list = append(list, fmt.Sprintf("%d:%d", tx[0], tx[1]))
+	}
+	message := []byte(fmt.Sprintf("from=%d&tx_list=%s", c.account, strings.Join(list, ";")))
+	mac, err := CBCMAC(message, c.key, make([]byte, aes.BlockSize))
+	if err != nil {
+		return nil, err
+	}
+	return append(message, mac...), nil
+}
+
+// Turns a request from the attacker's own account into one from the victim's.
+// The two "from=" values are the same length, so only the first block
+// changes. CBC XORs the IV into the first block before encrypting it, so
+// XORing the same difference into the IV leaves the MAC as it was.
+func forgeTransfer(request []byte, attacker, victim int) ([]byte, error) {
+	original := []byte(fmt.Sprintf("from=%d", attacker))
+	forged := []byte(fmt.Sprintf("from=%d", victim))
+	if len(original) != len(forged) || len(original) > aes.BlockSize {
+		return nil, fmt.Errorf("account numbers %d and %d aren't the same length", attacker, victim)
+	}
+
+	message := request[:len(request)-2*aes.BlockSize]
+	iv := request[len(message) : len(message)+aes.BlockSize]
+	mac := request[len(message)+aes.BlockSize:]
+
+	newMessage := append(append([]byte(nil), forged...), message[len(forged):]...)
+	diff := xor(message[:aes.BlockSize], newMessage[:aes.BlockSize])
+	result := append(newMessage, xor(iv, diff)...)
+	return append(result, mac...), nil
+}
+
+// Glues one of the attacker's own batch requests onto the end of a captured
+// request from the victim. After the victim's padded message, the CBC state
+// is the victim's MAC. XORing that into the first block of the attacker's
+// message puts the chain back where it would have been at the start of the
+// attacker's message, so the whole thing gets the attacker's MAC. The first
+// block of the attacker's message comes out as garbage, but the
+// transactions after it survive.
+func forgeMultiTransfer(captured, own []byte) []byte {
+	victimMessage := captured[:len(captured)-aes.BlockSize]
+	victimMAC := captured[len(victimMessage):]
+	ownMessage := own[:len(own)-aes.BlockSize]
+	ownMAC := own[len(ownMessage):]
+
+	forged := Pad(victimMessage)
+	forged = append(forged, xor(ownMessage[:aes.BlockSize], victimMAC)...)
+	forged = append(forged, ownMessage[aes.BlockSize:]...)
+	return append(forged, ownMAC...)
+}
+
+func Run() {
+	key := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal(err)
+	}
+	const victim, attacker, friend = 4521, 9137, 8822
+
+	// Part 1: the client picks the IV, and the attacker can change it.
+	s := &server{key: key}
+	attackerClient := &client{key: key, account: attacker}
+	request, err := attackerClient.transfer(attacker, 1000000)
+	if err != nil {
+		log.Fatal(err)
+	}
+	forged, err := forgeTransfer(request, attacker, victim)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = s.handleTransfer(forged)
+	want := transfer{from: victim, to: attacker, amount: 1000000}
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case s.hasTransfer(want):
+		fmt.Printf("attacker-controlled IV: got expected transfer: %+v\n", want)
+	default:
+		fmt.Printf("attacker-controlled IV: got unexpected ledger: %+v\n", s.ledger)
+	}
+
+	// Part 2: the IV is fixed, but the attacker can see the victim's
+	// requests go by.
+	s = &server{key: key}
+	victimClient := &client{key: key, account: victim}
+	captured, err := victimClient.multiTransfer([][2]int{{friend, 150}, {friend, 20}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := s.handleMultiTransfer(captured); err != nil {
+		log.Fatal(err)
+	}
+
+	// The attacker's first transaction is there to soak up the garbage block.
+	own, err := attackerClient.multiTransfer([][2]int{{attacker, 1}, {attacker, 1000000}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = s.handleMultiTransfer(forgeMultiTransfer(captured, own))
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case s.hasTransfer(want):
+		fmt.Printf("length extension: got expected transfer: %+v\n", want)
+	default:
+		fmt.Printf("length extension: got unexpected ledger: %+v\n", s.ledger)
+	}
+}
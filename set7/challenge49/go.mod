@@ -0,0 +1,3 @@
+module cryptopals/set7/challenge49
+
+go 1.15
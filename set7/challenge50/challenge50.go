@@ -0,0 +1,94 @@
+package challenge50
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"cryptopals/set2/challenge10"
+	"cryptopals/set7/challenge49"
+)
+
+// CBC-MAC with a fixed, public key and IV, used as a hash function. Anyone can
+// compute it, which is what makes it useless as one.
+var (
+	hashKey = []byte("YELLOW SUBMARINE")
+	hashIV  = make([]byte, aes.BlockSize)
+)
+
+// Returns the CBC-MAC hash of the message.
+func Hash(message []byte) ([]byte, error) {
+	return challenge49.CBCMAC(message, hashKey, hashIV)
+}
+
+// Returns a message which starts with the payload and hashes the same as the
+// target, which has to be at least a block long.
+//
+// The payload, with a JavaScript comment started after it, is filled out to a
+// whole number of blocks. CBC over those blocks leaves some state S. If the
+// next block is S XOR the target's first block, the block cipher sees
+// exactly what it saw for the target's first block (XORed with the zero IV),
+// and from there on the rest of the target hashes the way it always did.
+//
+// The crafted block is random-looking bytes, hidden in the comment. If it
+// happens to contain a line break, the comment would end early, so we add
+// another block of spaces before it and try again.
+func forge(payload, target []byte) ([]byte, error) {
+	if len(target) < aes.BlockSize {
+		return nil, fmt.Errorf("target must be at least %d bytes long", aes.BlockSize)
+	}
+
+	prefix := append(append([]byte(nil), payload...), "//"...)
+	for {
+		if rem := len(prefix) % aes.BlockSize; rem != 0 {
+			prefix = append(prefix, bytes.Repeat([]byte(" "), aes.BlockSize-rem)...)
+		}
+
+		ciphertext, err := challenge10.EncryptAESWithCBC(prefix, hashKey, hashIV)
+		if err != nil {
+			return nil, err
+		}
+		state := ciphertext[len(ciphertext)-aes.BlockSize:]
+
+		glue := make([]byte, aes.BlockSize)
+		for i := range glue {
+			glue[i] = state[i] ^ target[i]
+		}
+		if !bytes.ContainsAny(glue, "\r\n") {
+			forged := append(prefix, glue...)
+			return append(forged, target[aes.BlockSize:]...), nil
+		}
+
+		prefix = append(prefix, bytes.Repeat([]byte(" "), aes.BlockSize)...)
+	}
+}
+
+func Run() {
+	target := []byte("alert('MZA who was that?');\n")
+	want, _ := hex.DecodeString("296b8d7cb78a243dda4d0a61d33bbdd1")
+	got, err := Hash(target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		log.Fatal(fmt.Errorf("hash of the target is %x, want %x", got, want))
+	}
+	fmt.Printf("hash of the target: %x\n", got)
+
+	payload := []byte("alert('Ayo, the Wu is back!');")
+	forged, err := forge(payload, target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	forgedHash, err := Hash(forged)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case bytes.Equal(forgedHash, want) && bytes.HasPrefix(forged, payload):
+		fmt.Printf("got expected result: %q hashes to %x\n", forged, forgedHash)
+	default:
+		fmt.Printf("got unexpected result: %q hashes to %x\n", forged, forgedHash)
+	}
+}
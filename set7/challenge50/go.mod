@@ -0,0 +1,3 @@
+module cryptopals/set7/challenge50
+
+go 1.15
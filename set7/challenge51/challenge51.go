@@ -0,0 +1,187 @@
+package challenge51
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+
+	"cryptopals/set2/challenge10"
+	"cryptopals/set4/challenge25"
+	"cryptopals/set7/challenge49"
+)
+
+// CRIME: if attacker-controlled data is compressed together with a secret
+// before it's encrypted, the length of the ciphertext leaks how much the two
+// have in common.
+
+// The secret the attacker is after.
+const sessionID = "TmV2ZXIgcmV2ZWFsIHRoZSBXdS1UYW5nIFNlY3JldCE="
+
+// Returns the request the client sends, with the attacker's body in it.
+func formatRequest(body string) []byte {
+	return []byte(fmt.Sprintf("POST / HTTP/1.1\n"+
+		"Host: hapless.com\n"+
+		"Cookie: sessionid=%s\n"+
+		"Content-Length: %d\n"+
+		"%s", sessionID, len(body), body))
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Encrypts with AES-CTR under a new key and nonce. The ciphertext is as long
+// as the plaintext.
+func encryptCTR(plaintext []byte) ([]byte, error) {
+	key, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(8)
+	if err != nil {
+		return nil, err
+	}
+	return challenge25.EncryptAESWithCTR(plaintext, key, binary.LittleEndian.Uint64(nonce))
+}
+
+// Encrypts with AES-CBC under a new key and IV. The ciphertext is the
+// plaintext's length rounded up to the next whole block.
+func encryptCBC(plaintext []byte) ([]byte, error) {
+	key, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return challenge10.EncryptAESWithCBC(challenge49.Pad(plaintext), key, iv)
+}
+
+// Returns an oracle which formats a request with the body, compresses it,
+// encrypts it, and reports only the length of the ciphertext.
+func newOracle(encrypt func(plaintext []byte) ([]byte, error)) func(body string) (int, error) {
+	return func(body string) (int, error) {
+		compressed, err := compress(formatRequest(body))
+		if err != nil {
+			return 0, err
+		}
+		ciphertext, err := encrypt(compressed)
+		if err != nil {
+			return 0, err
+		}
+		return len(ciphertext), nil
+	}
+}
+
+// The characters a session ID can have, plus the newline that ends it.
+const candidates = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=\n"
+
+// Characters which don't appear anywhere in the request, so they don't
+// compress against it.
+const junkAlphabet = "!\"#$%&'()*,;<>?@[\\]^_`{|}~"
+
+// Returns n junk characters, which shouldn't compress well against anything
+// in the request, or each other.
+func junk(n int) (string, error) {
+	b, err := randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = junkAlphabet[int(b[i])%len(junkAlphabet)]
+	}
+	return string(b), nil
+}
+
+// Recovers the session ID one character at a time. A body repeating
+// "sessionid=" and what we know of the ID compresses to a back-reference to
+// the cookie, and the reference gets one character longer -- and the
+// ciphertext usually a little shorter -- if the next character is right.
+//
+// Lengths are measured in whole bytes, or whole blocks for CBC, so often
+// several candidates come out the same. Then we put some uncompressible
+// junk in front of the guess and try again. With enough junk, the
+// compressed length lands right under a byte or block boundary, where the
+// right guess stays under it and the wrong ones tip over.
+func recoverSessionID(oracle func(body string) (int, error), maxJunk int) (string, error) {
+	known := ""
+	for {
+		var next byte
+		found := false
+		for junkLen := 0; junkLen <= maxJunk && !found; junkLen++ {
+			padding, err := junk(junkLen)
+			if err != nil {
+				return "", err
+			}
+
+			best, bestCount := 0, 0
+			for i := 0; i < len(candidates); i++ {
+				n, err := oracle(padding + "sessionid=" + known + string(candidates[i]))
+				if err != nil {
+					return "", err
+				}
+				switch {
+				case bestCount == 0 || n < best:
+					best, bestCount, next = n, 1, candidates[i]
+				case n == best:
+					bestCount++
+				}
+			}
+			found = bestCount == 1
+		}
+		if !found {
+			return "", fmt.Errorf("couldn't tell which character comes after %q", known)
+		}
+
+		if next == '\n' {
+			return known, nil
+		}
+		known += string(next)
+	}
+}
+
+func Run() {
+	modes := []struct {
+		name    string
+		encrypt func(plaintext []byte) ([]byte, error)
+		maxJunk int
+	}{
+		{"CTR", encryptCTR, 16},
+		{"CBC", encryptCBC, 4 * aes.BlockSize},
+	}
+	for _, mode := range modes {
+		got, err := recoverSessionID(newOracle(mode.encrypt), mode.maxJunk)
+		switch {
+		case err != nil:
+			log.Fatal(fmt.Errorf("%s: %v", mode.name, err))
+		case got == sessionID:
+			fmt.Printf("%s: got expected session ID: %q\n", mode.name, got)
+		default:
+			fmt.Printf("%s: got unexpected session ID: %q\n", mode.name, got)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module cryptopals/set7/challenge51
+
+go 1.15
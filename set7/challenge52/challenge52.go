@@ -0,0 +1,240 @@
+package challenge52
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+
+	"cryptopals/set4/challenge28"
+)
+
+// A Merkle-Damgård hash is only as strong as its compression function's
+// state. Here the state is tiny, so single collisions are cheap -- and Joux
+// showed that with n of them, we get 2^n messages that all collide.
+// https://www.iacr.org/archive/crypto2004/31520306/multicollisions.pdf
+
+// The hash processes its input in AES blocks.
+const BlockSize = aes.BlockSize
+
+// A weak Merkle-Damgård hash. The compression function uses the state,
+// padded out to 16 bytes, as an AES key, encrypts the message block with it,
+// and keeps the first Bits bits of the result as the new state.
+type MDHash struct {
+	Bits    int
+	Initial uint32
+
+	// How many times the compression function has been called.
+	Calls int
+}
+
+// Returns a hash with a state of the given number of bits, between 16 and 32,
+// starting from the given initial state.
+func NewMDHash(bits int, initial uint32) (*MDHash, error) {
+	if bits < 16 || bits > 32 {
+		return nil, fmt.Errorf("state must be 16 to 32 bits, got %d", bits)
+	}
+	h := &MDHash{Bits: bits}
+	h.Initial = initial & h.mask()
+	return h, nil
+}
+
+func (h *MDHash) mask() uint32 {
+	return uint32(1<<uint(h.Bits) - 1)
+}
+
+// Runs the compression function on one block.
+func (h *MDHash) Compress(state uint32, block []byte) uint32 {
+	h.Calls++
+	key := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint32(key, state)
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		// A 16-byte key is always valid.
+		panic(err)
+	}
+	out := make([]byte, aes.BlockSize)
+	c.Encrypt(out, block)
+	return binary.BigEndian.Uint32(out) >> uint(32-h.Bits)
+}
+
+// Runs the compression function over each block of a message that's a whole
+// number of blocks long, starting from the state.
+func (h *MDHash) CompressBlocks(state uint32, blocks []byte) uint32 {
+	for i := 0; i < len(blocks); i += BlockSize {
+		state = h.Compress(state, blocks[i:i+BlockSize])
+	}
+	return state
+}
+
+// Returns the Merkle-Damgård padding for a message of the given length: a 1
+// bit, zeros, and the length in bits, so that the total is a whole number
+// of blocks. It's SHA-1's padding, on 16-byte blocks.
+func Padding(length int) []byte {
+	return challenge28.MDPadding(uint64(length), BlockSize, binary.BigEndian)
+}
+
+// Returns the hash of the message.
+func (h *MDHash) Sum(message []byte) uint32 {
+	padded := append(append([]byte(nil), message...), Padding(len(message))...)
+	return h.CompressBlocks(h.Initial, padded)
+}
+
+// Returns n random bytes.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Finds two different blocks which compress to the same state from the given
+// one, by the birthday paradox: about 2^(Bits/2) tries. Returns the blocks
+// and the state they lead to.
+func FindCollision(h *MDHash, state uint32) ([]byte, []byte, uint32, error) {
+	seen := make(map[uint32][]byte)
+	for {
+		block, err := randomBytes(BlockSize)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		next := h.Compress(state, block)
+		if other, ok := seen[next]; ok && !bytes.Equal(other, block) {
+			return other, block, next, nil
+		}
+		seen[next] = block
+	}
+}
+
+// A chain of block collisions. At each step, either of the two blocks leads
+// to the same state, so any choice of one block per step gives a message
+// with the same hash.
+type Multicollision struct {
+	Pairs [][2][]byte
+	State uint32
+}
+
+// Finds n block collisions, each starting where the last one ended. That's n
+// collision searches for 2^n colliding messages.
+func FindMulticollision(h *MDHash, state uint32, n int) (*Multicollision, error) {
+	m := &Multicollision{State: state}
+	return m, m.Extend(h, n)
+}
+
+// Adds n more collisions to the end of the chain.
+func (m *Multicollision) Extend(h *MDHash, n int) error {
+	for i := 0; i < n; i++ {
+		a, b, next, err := FindCollision(h, m.State)
+		if err != nil {
+			return err
+		}
+		m.Pairs = append(m.Pairs, [2][]byte{a, b})
+		m.State = next
+	}
+	return nil
+}
+
+// Returns all 2^n messages. Message i takes the second block of pair j when
+// bit j of i is set.
+func (m *Multicollision) Messages() [][]byte {
+	var messages [][]byte
+	for i := 0; i < 1<<uint(len(m.Pairs)); i++ {
+		var message []byte
+		for j, pair := range m.Pairs {
+			message = append(message, pair[(i>>uint(j))&1]...)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// Finds two messages which collide under both f and g, i.e. under the
+// cascaded hash f(x) || g(x), which has f.Bits + g.Bits bits of state.
+//
+// We make 2^(g.Bits/2) messages that collide under f, which is only g.Bits/2
+// searches for f collisions. By the birthday paradox, two of them probably
+// collide under g too. If not, we add another f collision, which doubles the
+// number of messages, and look again.
+func findCascadeCollision(f, g *MDHash) ([]byte, []byte, error) {
+	m, err := FindMulticollision(f, f.Initial, g.Bits/2)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		seen := make(map[uint32][]byte)
+		for _, message := range m.Messages() {
+			state := g.CompressBlocks(g.Initial, message)
+			if other, ok := seen[state]; ok {
+				return other, message, nil
+			}
+			seen[state] = message
+		}
+		if err := m.Extend(f, 1); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Checks that every message in a multicollision has the same hash.
+func checkMulticollision(h *MDHash, n int) (bool, error) {
+	m, err := FindMulticollision(h, h.Initial, n)
+	if err != nil {
+		return false, err
+	}
+	messages := m.Messages()
+	if len(messages) != 1<<uint(n) {
+		return false, nil
+	}
+	want := h.Sum(messages[0])
+	for i, message := range messages {
+		if i > 0 && bytes.Equal(message, messages[0]) {
+			return false, nil
+		}
+		if h.Sum(message) != want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func Run() {
+	// The challenge's cheap f and more expensive g. The sizes are
+	// parameters, so they can be turned up to see how the cost grows.
+	f, err := NewMDHash(16, 0x1234)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g, err := NewMDHash(24, 0xabcdef)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ok, err := checkMulticollision(f, 8)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkMulticollision passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkMulticollision failed"))
+	}
+	f.Calls = 0
+
+	a, b, err := findCascadeCollision(f, g)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fCalls, gCalls := f.Calls, g.Calls
+	switch {
+	case !bytes.Equal(a, b) && f.Sum(a) == f.Sum(b) && g.Sum(a) == g.Sum(b):
+		fmt.Printf("got expected result: two different %d-block messages both hash to %04x||%06x\n",
+			len(a)/BlockSize, f.Sum(a), g.Sum(a))
+	default:
+		fmt.Printf("got unexpected result: %04x||%06x and %04x||%06x\n", f.Sum(a), g.Sum(a), f.Sum(b), g.Sum(b))
+	}
+	fmt.Printf("%d calls to f and %d calls to g, against about 2^%d for a birthday attack on a %d-bit hash\n",
+		fCalls, gCalls, (f.Bits+g.Bits)/2, f.Bits+g.Bits)
+}
@@ -0,0 +1,3 @@
+module cryptopals/set7/challenge52
+
+go 1.15
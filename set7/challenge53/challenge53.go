@@ -0,0 +1,246 @@
+package challenge53
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"time"
+
+	"cryptopals/set7/challenge52"
+)
+
+// Kelsey and Schneier's second preimage attack on long messages, from "Second
+// Preimages on n-bit Hash Functions for Much Less than 2^n Work" (2005).
+// A long message passes through lots of intermediate states, and hitting any
+// one of them is much easier than hitting the final hash. The length padding
+// is supposed to stop us from splicing in at a different position, but an
+// expandable message lets us fix up the length.
+// https://www.schneier.com/wp-content/uploads/2016/02/paper-preimages.pdf
+
+const blockSize = challenge52.BlockSize
+
+// Returns n random bytes.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Finds a single block, and a message of dummyBlocks blocks of zeros followed
+// by one more block, which both lead from state to the same state. Returns
+// the single block, the long message's last block, and the state.
+//
+// Both sides are a birthday search over their last block, so we alternate
+// between them, keeping a map of the states each side has reached.
+func findLengthCollision(h *challenge52.MDHash, state uint32, dummyBlocks int) ([]byte, []byte, uint32, error) {
+	longState := h.CompressBlocks(state, make([]byte, dummyBlocks*blockSize))
+
+	short := make(map[uint32][]byte)
+	long := make(map[uint32][]byte)
+	for {
+		a, err := randomBytes(blockSize)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		next := h.Compress(state, a)
+		if b, ok := long[next]; ok {
+			return a, b, next, nil
+		}
+		short[next] = a
+
+		b, err := randomBytes(blockSize)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		next = h.Compress(longState, b)
+		if a, ok := short[next]; ok {
+			return a, b, next, nil
+		}
+		long[next] = b
+	}
+}
+
+// An expandable message: a set of messages of every length from k to
+// k + 2^k - 1 blocks, which all lead to the same state.
+//
+// It's made of k pieces, each with a short option (one block) and a long
+// option (2^i blocks of zeros, then one block), where i counts down from
+// k-1 to 0. Picking the long option for piece i adds 2^i blocks, so every
+// extra length up to 2^k - 1 is some combination of them.
+type expandableMessage struct {
+	k     int
+	short [][]byte
+	long  [][]byte
+	state uint32
+}
+
+// Builds an expandable message for lengths k to k + 2^k - 1, starting from
+// the hash's initial state.
+func newExpandableMessage(h *challenge52.MDHash, k int) (*expandableMessage, error) {
+	e := &expandableMessage{k: k, state: h.Initial}
+	for i := k - 1; i >= 0; i-- {
+		dummyBlocks := 1 << uint(i)
+		a, b, next, err := findLengthCollision(h, e.state, dummyBlocks)
+		if err != nil {
+			return nil, err
+		}
+		e.short = append(e.short, a)
+		e.long = append(e.long, append(make([]byte, dummyBlocks*blockSize), b...))
+		e.state = next
+	}
+	return e, nil
+}
+
+// Returns the message with the given number of blocks.
+func (e *expandableMessage) message(blocks int) ([]byte, error) {
+	extra := blocks - e.k
+	if extra < 0 || extra >= 1<<uint(e.k) {
+		return nil, fmt.Errorf("can only make %d to %d blocks, not %d", e.k, e.k+1<<uint(e.k)-1, blocks)
+	}
+
+	var message []byte
+	for j := range e.short {
+		// Piece j's long option adds 2^(k-1-j) blocks.
+		if extra&(1<<uint(e.k-1-j)) != 0 {
+			message = append(message, e.long[j]...)
+		} else {
+			message = append(message, e.short[j]...)
+		}
+	}
+	return message, nil
+}
+
+// Returns a map from each intermediate state of the message to the number of
+// blocks it takes to reach it, for block counts from minBlocks to maxBlocks.
+// Where a state comes up more than once, the first is kept.
+func intermediateStates(h *challenge52.MDHash, message []byte, minBlocks, maxBlocks int) map[uint32]int {
+	states := make(map[uint32]int)
+	state := h.Initial
+	for i := 0; i < len(message)/blockSize && i < maxBlocks; i++ {
+		state = h.Compress(state, message[i*blockSize:(i+1)*blockSize])
+		if _, ok := states[state]; !ok && i+1 >= minBlocks {
+			states[state] = i + 1
+		}
+	}
+	return states
+}
+
+// Compression calls made in each phase of the attack.
+type phaseCalls struct {
+	expandable, intermediate, bridge int
+}
+
+// Returns a different message of the same length as the target, with the
+// same hash. The target must be a whole number of blocks, 2^k of them.
+//
+// The expandable message leads to some state from any of its lengths. We
+// look for a bridge block from that state to any intermediate state of the
+// target that's more than k blocks in, then pick the expandable message's
+// length so that prefix + bridge is as long as the part of the target it
+// replaces. The rest of the target, and the padding, stay the same.
+func secondPreimage(h *challenge52.MDHash, target []byte, k int) ([]byte, phaseCalls, error) {
+	var calls phaseCalls
+	n := len(target) / blockSize
+	if len(target)%blockSize != 0 || n != 1<<uint(k) {
+		return nil, calls, fmt.Errorf("target must be 2^%d whole blocks", k)
+	}
+
+	start := h.Calls
+	e, err := newExpandableMessage(h, k)
+	if err != nil {
+		return nil, calls, err
+	}
+	calls.expandable = h.Calls - start
+
+	// The prefix is at least k blocks, so the bridge lands at block k+1 or
+	// later. Landing on the last block would leave nothing of the target.
+	start = h.Calls
+	states := intermediateStates(h, target, k+1, n-1)
+	calls.intermediate = h.Calls - start
+
+	start = h.Calls
+	var bridge []byte
+	var blocks int
+	for {
+		candidate, err := randomBytes(blockSize)
+		if err != nil {
+			return nil, calls, err
+		}
+		if i, ok := states[h.Compress(e.state, candidate)]; ok {
+			bridge, blocks = candidate, i
+			break
+		}
+	}
+	calls.bridge = h.Calls - start
+
+	prefix, err := e.message(blocks - 1)
+	if err != nil {
+		return nil, calls, err
+	}
+	forged := append(prefix, bridge...)
+	return append(forged, target[blocks*blockSize:]...), calls, nil
+}
+
+// Checks that every length the expandable message offers leads to the same
+// state.
+func checkExpandableMessage(h *challenge52.MDHash, k int) (bool, error) {
+	e, err := newExpandableMessage(h, k)
+	if err != nil {
+		return false, err
+	}
+	for blocks := k; blocks < k+1<<uint(k); blocks++ {
+		message, err := e.message(blocks)
+		if err != nil {
+			return false, err
+		}
+		if len(message) != blocks*blockSize || h.CompressBlocks(h.Initial, message) != e.state {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func Run() {
+	h, err := challenge52.NewMDHash(32, 0x01234567)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ok, err := checkExpandableMessage(h, 4)
+	switch {
+	case err != nil:
+		log.Fatal(err)
+	case ok:
+		fmt.Println("checkExpandableMessage passed")
+	case !ok:
+		log.Fatal(fmt.Errorf("checkExpandableMessage failed"))
+	}
+
+	// A 2^16-block message. Finding a bridge to any of its intermediate
+	// states takes about 2^32 / 2^16 tries, instead of the 2^32 it would
+	// take to hit the final hash.
+	const k = 16
+	target, err := randomBytes(blockSize << k)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	startTime := time.Now()
+	forged, calls, err := secondPreimage(h, target, k)
+	if err != nil {
+		log.Fatal(err)
+	}
+	elapsed := time.Since(startTime)
+
+	switch {
+	case !bytes.Equal(forged, target) && len(forged) == len(target) && h.Sum(forged) == h.Sum(target):
+		fmt.Printf("got expected result: a different %d-block message with hash %08x\n", len(forged)/blockSize, h.Sum(forged))
+	default:
+		fmt.Printf("got unexpected result: hash %08x, want %08x\n", h.Sum(forged), h.Sum(target))
+	}
+	fmt.Printf("compression calls: %d for the expandable message, %d for the target's intermediate states, %d for the bridge (%s)\n",
+		calls.expandable, calls.intermediate, calls.bridge, elapsed.Round(time.Millisecond))
+}
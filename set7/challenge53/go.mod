@@ -0,0 +1,3 @@
+module cryptopals/set7/challenge53
+
+go 1.15
@@ -0,0 +1,36 @@
+module cryptopals/set7
+
+go 1.15
+
+replace cryptopals/set7/challenge49 => ./challenge49
+
+require (
+	cryptopals/set1/challenge7 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set2/challenge9 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set2/challenge10 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge25 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set4/challenge28 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set7/challenge49 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set7/challenge50 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set7/challenge51 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set7/challenge52 v0.0.0-00010101000000-000000000000 // indirect
+	cryptopals/set7/challenge53 v0.0.0-00010101000000-000000000000 // indirect
+)
+
+replace cryptopals/set1/challenge7 => ../set1/challenge7
+
+replace cryptopals/set2/challenge9 => ../set2/challenge9
+
+replace cryptopals/set2/challenge10 => ../set2/challenge10
+
+replace cryptopals/set7/challenge50 => ./challenge50
+
+replace cryptopals/set7/challenge51 => ./challenge51
+
+replace cryptopals/set4/challenge25 => ../set4/challenge25
+
+replace cryptopals/set7/challenge52 => ./challenge52
+
+replace cryptopals/set7/challenge53 => ./challenge53
+
+replace cryptopals/set4/challenge28 => ../set4/challenge28
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"cryptopals/set7/challenge49"
+	"cryptopals/set7/challenge50"
+	"cryptopals/set7/challenge51"
+	"cryptopals/set7/challenge52"
+	"cryptopals/set7/challenge53"
+)
+
+func runChallenge(runFn func(), challengeNumber int) {
+	fmt.Printf("Challenge %d:\n", challengeNumber)
+	runFn()
+	fmt.Println()
+}
+
+func main() {
+	runChallenge(challenge49.Run, 49)
+	runChallenge(challenge50.Run, 50)
+	runChallenge(challenge51.Run, 51)
+	runChallenge(challenge52.Run, 52)
+	runChallenge(challenge53.Run, 53)
+}